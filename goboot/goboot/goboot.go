@@ -32,6 +32,7 @@ import (
 	"io"
 	"io/fs"
 	"io/ioutil"
+	"log/slog"
 	"math"
 	"math/rand"
 	"mime"
@@ -41,6 +42,7 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path"
 	"path/filepath"
 	"reflect"
@@ -48,6 +50,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -60,7 +65,6 @@ import (
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	goredis "github.com/redis/go-redis/v9"
-	"gopkg.in/yaml.v3"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -76,6 +80,9 @@ var assetsFiles embed.FS
 // 默认配置文件名称
 const DefaultConfigFile string = "./goboot.yml"
 
+// 默认优雅停机等待时间，单位秒
+const DefaultShutdownTimeout int = 30
+
 // 默认 Banner 内容
 // Banner在线生成三方网址
 // https://www.bootschool.net/ascii
@@ -104,6 +111,8 @@ type ApiResp struct {
 	Code int         `json:"code"`
 	Msg  string      `json:"msg"`
 	Data interface{} `json:"data"`
+	// 错误响应时的请求ID，用于关联日志
+	RequestId string `json:"requestId,omitempty"`
 }
 
 // 绑定基础函数
@@ -135,12 +144,20 @@ func (api *ApiResp) Error(code int, msg string) *ApiResp {
 	return api
 }
 
+// 错误响应时添加当前请求的请求ID
+func (api *ApiResp) WithRequestId(c *gin.Context) *ApiResp {
+	if api.Code != ApiCodeOk {
+		api.RequestId = GetRequestId(c)
+	}
+	return api
+}
+
 // 绑定具有响应能力的函数
 func (api *ApiResp) GinRet(c *gin.Context, code int, msg string, data interface{}) *ApiResp {
 	api.Code = code
 	api.Msg = msg
 	api.Data = data
-	c.JSON(200, api)
+	c.JSON(200, api.WithRequestId(c))
 	return api
 }
 
@@ -156,7 +173,7 @@ func (api *ApiResp) GinErr(c *gin.Context, msg string) *ApiResp {
 	api.Code = APiCodeErr
 	api.Msg = msg
 	api.Data = nil
-	c.JSON(200, api)
+	c.JSON(200, api.WithRequestId(c))
 	return api
 }
 
@@ -164,7 +181,7 @@ func (api *ApiResp) GinError(c *gin.Context, code int, msg string) *ApiResp {
 	api.Code = code
 	api.Msg = msg
 	api.Data = nil
-	c.JSON(200, api)
+	c.JSON(200, api.WithRequestId(c))
 	return api
 }
 
@@ -250,7 +267,7 @@ type CtxResp struct {
 
 // 定义响应ApiResp的JSON函数
 func (api *CtxResp) ApiJsonRet(code int, msg string, data interface{}) *CtxResp {
-	api.Context.JSON(200, ApiRet(code, msg, data))
+	api.Context.JSON(200, ApiRet(code, msg, data).WithRequestId(api.Context))
 	return api
 }
 func (api *CtxResp) ApiJsonOk(data interface{}) *CtxResp {
@@ -258,11 +275,11 @@ func (api *CtxResp) ApiJsonOk(data interface{}) *CtxResp {
 	return api
 }
 func (api *CtxResp) ApiJsonErr(msg string) *CtxResp {
-	api.Context.JSON(200, ApiErr(msg))
+	api.Context.JSON(200, ApiErr(msg).WithRequestId(api.Context))
 	return api
 }
 func (api *CtxResp) ApiJsonError(code int, msg string) *CtxResp {
-	api.Context.JSON(200, ApiError(code, msg))
+	api.Context.JSON(200, ApiError(code, msg).WithRequestId(api.Context))
 	return api
 }
 
@@ -299,10 +316,24 @@ func (api *CtxResp) SessionSet(key interface{}, val interface{}) *CtxResp {
 // /////////////////////////////////////////////////////////
 // goboot Log区
 // /////////////////////////////////////////////////////////
-// 控制台日志输出
+// 日志输出，使用默认日志器
+// level 为 DEBUG/INFO/WARN/ERROR，不区分大小写
 func Log(level string, format string, args ...interface{}) {
-	time := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Println(fmt.Sprintf("[%v] [%v]", time, level), fmt.Sprintf(format, args...))
+	logLevel, err := ParseLogLevel(level)
+	if err != nil {
+		logLevel = slog.LevelInfo
+	}
+	logger := GetDefaultLogger()
+	// 级别未开启时不进行格式化
+	if enabler, ok := logger.(interface {
+		Enabled(context.Context, slog.Level) bool
+	}); ok && !enabler.Enabled(context.Background(), logLevel) {
+		return
+	}
+	logger.Log(context.Background(), logLevel, fmt.Sprintf(format, args...))
+}
+func LogDebug(format string, args ...interface{}) {
+	Log("DEBUG", format, args...)
 }
 func LogInfo(format string, args ...interface{}) {
 	Log("INFO ", format, args...)
@@ -321,13 +352,22 @@ func LogError(format string, args ...interface{}) {
 // 配置结构
 type GobootConfig struct {
 	Goboot     Goboot `yaml:"goboot"`
-	ConfigFile string
+	ConfigFile string `yaml:"-"`
+	// 实际加载的配置文件列表，主配置文件在前，环境配置文件按激活顺序在后
+	ConfigFiles []string `yaml:"-"`
+	// 每个配置项来自的配置文件，键为点号路径
+	ConfigSources map[string]string `yaml:"-"`
+	// 合并并解析占位符后的完整配置，包含自定义的配置节点
+	Properties map[string]interface{} `yaml:"-"`
+	// 配置解析时发现的错误，包括不认识的键，类型错误，无法解析的占位符
+	ConfigErrors []string `yaml:"-"`
 }
 
 // 配置根节点
 type Goboot struct {
 	Application Application `yaml:"application"`
 	Profiles    Profiles    `yaml:"profiles"`
+	Logging     Logging     `yaml:"logging"`
 	Server      Server      `yaml:"server"`
 }
 
@@ -338,13 +378,18 @@ type Application struct {
 
 // 环境配置
 type Profiles struct {
-	Active string `yaml:"active"`
+	Active    string `yaml:"active"`    // 激活的环境，多个使用逗号分隔
+	ListMerge string `yaml:"listMerge"` // 环境配置中列表的合并方式，replace/append，默认replace
 }
 
 // 服务器配置
 type Server struct {
-	Port       int    `yaml:"port"`
-	BannerPath string `yaml:"bannerPath"`
+	Port            int    `yaml:"port"`
+	BannerPath      string `yaml:"bannerPath"`
+	ShutdownTimeout int    `yaml:"shutdownTimeout"` // 优雅停机等待请求处理完成的时间，单位秒
+	// 信任的代理IP或者网段，只有来自这些地址的请求才使用 X-Forwarded-For/X-Real-IP 获取客户端IP
+	// 默认不信任任何代理，客户端IP为连接的地址，用于访问日志、限流等
+	TrustedProxies []string `yaml:"trustedProxies"`
 
 	StaticResources   StaticResources   `yaml:"staticResources"`
 	TemplateResources TemplateResources `yaml:"templateResources"`
@@ -359,6 +404,16 @@ type Server struct {
 	Gorm              Gorm              `yaml:"gorm"`
 
 	FileServer FileServer `yaml:"fileServer"`
+	HotReload  HotReload  `yaml:"hotReload"`
+	Trace      Trace      `yaml:"trace"`
+	Management Management `yaml:"management"`
+	Metrics    Metrics    `yaml:"metrics"`
+	Validation Validation `yaml:"validation"`
+	OpenApi    OpenApi    `yaml:"openapi"`
+	Auth       Auth       `yaml:"auth"`
+	Jwt        Jwt        `yaml:"jwt"`
+	Permission Permission `yaml:"permission"`
+	RateLimit  RateLimit  `yaml:"rateLimit"`
 }
 
 // 静态资源项配置
@@ -416,9 +471,13 @@ type Gorm struct {
 
 // HTTPS配置
 type Https struct {
-	Enable  bool   `yaml:"enable"`
-	PemPath string `yaml:"pemPath"`
-	KeyPath string `yaml:"keyPath"`
+	Enable           bool     `yaml:"enable"`
+	PemPath          string   `yaml:"pemPath"`
+	KeyPath          string   `yaml:"keyPath"`
+	MinVersion       string   `yaml:"minVersion"`       // 最低TLS版本，TLS1.0/TLS1.1/TLS1.2/TLS1.3，默认TLS1.2
+	CipherSuites     []string `yaml:"cipherSuites"`     // 允许的加密套件名称，为空时使用go的默认值
+	RedirectHttpPort int      `yaml:"redirectHttpPort"` // 大于0时，在此端口监听http并301重定向到https
+	SelfSigned       bool     `yaml:"selfSigned"`       // pem/key文件不存在时，自动生成自签名证书，仅用于开发环境
 }
 
 // 代理配置
@@ -437,6 +496,8 @@ type Proxy struct {
 type Mapping struct {
 	Enable bool     `yaml:"enable"`
 	Items  []string `yaml:"items"`
+	// 映射函数和控制器函数返回的数据使用 ApiOk 包装后响应
+	WrapApiResp bool `yaml:"wrapApiResp"`
 }
 
 // GZIP配置
@@ -474,6 +535,50 @@ type GobootApplication struct {
 	Controllers []GobootController
 	Db          *sql.DB
 	GormDb      *gorm.DB
+	HttpServer  *http.Server
+	// https 开启重定向时的 http 服务
+	RedirectServer *http.Server
+	// 管理端点使用单独端口时的服务
+	ManagementServer *http.Server
+	// 指标注册表，可以注册自定义指标
+	Metrics *MetricsRegistry
+	// 错误处理器链，使用 AddErrorHandlers 添加
+	ErrorHandlers []ErrorHandler
+	// 映射函数的拦截器链，使用 AddInterceptors 添加
+	Interceptors []*Interceptor
+	// 使用 BindConfig 绑定的自定义配置，键为结构体指针类型
+	ConfigBeans map[reflect.Type]interface{}
+	// 令牌存储，开启认证时按照配置创建，可以替换为自定义实现
+	TokenStore TokenStore
+	// JWT签发和校验，开启JWT时创建
+	JwtManager *JwtManager
+	// 加载当前用户的授权，未设置时使用 Principal.Roles
+	PermissionProvider PermissionProvider
+	// 限流计数存储，开启限流时按照配置创建，可以替换为自定义实现
+	RateLimitStore RateLimitStore
+
+	// 启动前发现的错误，Run 时统一打印并退出
+	startupErrors []string
+	// 使用 BindConfig 绑定的配置，键为配置路径
+	configBindings map[string]interface{}
+
+	// 配置热加载状态
+	reload configReloadState
+	// 自定义健康检查项
+	healthChecks healthChecks
+	// 内置指标，开启指标时有效
+	metrics *gobootMetrics
+	// 自动映射和控制器的路由表，Run 时编译，热加载 mapping 时替换
+	mappings atomic.Pointer[mappingTable]
+	// 注册的参数注入器，使用 AddArgInjector 添加
+	argInjectors map[reflect.Type]ArgInjector
+	// 服务容器，使用 Provide/Register 注册
+	container *serviceContainer
+	// 限流规则，开启限流时有效
+	rateLimiter *rateLimiter
+
+	shutdownOnce sync.Once
+	shutdownErr  error
 }
 
 // 控制器，需要提供基础路径
@@ -522,6 +627,9 @@ type GobootLifecycleListener struct {
 	OnPrepared                 []GobootListener
 	OnBeforeBanner             []GobootListener
 	OnBeforeRun                []GobootListener
+	OnBeforeShutdown           []GobootListener
+	OnShutdown                 []GobootListener
+	OnConfigReloaded           []GobootListener
 }
 
 // 处理器必须是struct类型的指针
@@ -558,6 +666,7 @@ func GetDefaultApplication() *GobootApplication {
 
 // 从指定文件读取应用配置
 // 始终返回配置，第二个返回值表示是否正确读取了配置
+// 会解析配置中的占位符和加密值
 // 不会处理Profiles
 func ReadGobootConfig(cfgFile string) (config *GobootConfig, ok bool) {
 	// 初始化默认配置
@@ -570,28 +679,30 @@ func ReadGobootConfig(cfgFile string) (config *GobootConfig, ok bool) {
 	}
 
 	// 读取配置文件
-	bytes, err := ioutil.ReadFile(cfgFile)
-	if err != nil {
-		LogWarn("read config file %v error of %v", cfgFile, err)
-	}
+	cfgMap, rok := ReadGobootConfigMap(cfgFile)
 
-	// 解析yaml到结构
-	if err == nil {
-		err = yaml.Unmarshal(bytes, config)
-		if err != nil {
-			LogWarn("parse yaml config file %v error of %v", cfgFile, err)
-		} else {
-			ok = true
-			config.ConfigFile = cfgFile
+	// 解析占位符后，转换到结构
+	if rok {
+		errs := ResolveConfigPlaceholders(cfgMap)
+		errs = append(errs, decodeGobootConfigMap(cfgMap, nil, config)...)
+		for _, item := range errs {
+			LogWarn("parse yaml config file %v error of %v", cfgFile, item)
 		}
+		ok = true
+		config.ConfigFile = cfgFile
+		config.ConfigFiles = []string{cfgFile}
+		config.Properties = cfgMap
+		config.ConfigErrors = errs
 	}
 
 	return
 }
 
 // 从指定的配置文件获取配置
-// 会处理Profiles的重定向配置
-// 至多重定向配置一次
+// 会处理Profiles的环境配置，按照激活顺序深度合并到主配置上
+// 合并后解析配置中的占位符和加密值
+// 之后使用环境变量和命令行参数覆盖配置
+// 优先级：goboot.yml < goboot-${profile}.yml < 环境变量 < 命令行参数
 func ResolveGobootConfig(cfgFile string) *GobootConfig {
 	// 初始化默认配置
 	config := &GobootConfig{
@@ -602,28 +713,21 @@ func ResolveGobootConfig(cfgFile string) *GobootConfig {
 		},
 	}
 
-	// 从配置文件读取配置
-	cfg, ok := ReadGobootConfig(cfgFile)
+	// 从配置文件读取并合并配置
+	cfgMap, sources, files, ok := LoadGobootConfigMap(cfgFile)
 	if ok {
-		LogInfo("load config yaml file %v", cfgFile)
-		// 判断是否具有active激活配置
-		if cfg.Goboot.Profiles.Active != "" {
-			LogInfo("find profile active %v", cfg.Goboot.Profiles.Active)
-			redirectCfgFile := fmt.Sprintf("goboot-%v.yml", cfg.Goboot.Profiles.Active)
-			// 读取激活配置
-			rcfg, rok := ReadGobootConfig(redirectCfgFile)
-			// 正确读取激活配置，则使用激活配置，否则还是原配置
-			if rok {
-				LogInfo("override config yaml file %v", redirectCfgFile)
-				config = rcfg
-			} else {
-				LogInfo("fallback use config yaml file %v", cfgFile)
-				config = cfg
-			}
-		} else {
-			config = cfg
-		}
-	}
+		// 合并后再解析占位符，使得引用可以跨配置文件
+		errs := ResolveConfigPlaceholders(cfgMap)
+		errs = append(errs, decodeGobootConfigMap(cfgMap, sources, config)...)
+		config.ConfigFile = cfgFile
+		config.ConfigFiles = files
+		config.ConfigSources = sources
+		config.Properties = cfgMap
+		config.ConfigErrors = errs
+		LogConfigSources(sources)
+	}
+
+	ApplyGobootConfigOverrides(config)
 	return config
 }
 
@@ -660,20 +764,41 @@ func GetConfigApplication(config *GobootConfig, listener *GobootLifecycleListene
 	}
 	// 实例化应用结构
 	boot := &GobootApplication{
-		App:       gin.Default(),
+		App:       NewGinEngine(),
 		Config:    config,
 		Handlers:  []interface{}{},
 		Listeners: listener,
+		Metrics:   NewMetricsRegistry(),
 	}
 
 	// 调用监听器
 	LogInfo("goboot configed.")
 	invokeListeners(boot, boot.Listeners.OnConfiged)
 
+	// 校验配置，错误记录为启动错误，与绑定配置等错误一起在 Run 时打印并退出
+	// 配置有误时不再初始化redis、数据源等组件
+	errs := ValidateGobootConfig(boot.Config)
+	if len(errs) > 0 {
+		boot.startupErrors = append(boot.startupErrors, errs...)
+		return boot
+	}
+
+	// 配置日志
+	err := ConfigureLogging(boot.Config.Goboot.Logging)
+	if err != nil {
+		LogError("goboot logging config error of %v", err)
+		boot.startupErrors = append(boot.startupErrors, fmt.Sprintf("goboot.logging: %v", err))
+	}
+
 	engine := boot.App
 
 	server := boot.Config.Goboot.Server
 
+	// 配置信任的代理，客户端IP只从信任的代理的转发头中获取
+	if err := engine.SetTrustedProxies(server.TrustedProxies); err != nil {
+		boot.startupErrors = append(boot.startupErrors, fmt.Sprintf("goboot.server.trustedProxies: %v", err))
+	}
+
 	// 配置 redis
 	if server.Redis.Enable {
 		if server.Redis.Port == 0 {
@@ -700,6 +825,9 @@ func GetConfigApplication(config *GobootConfig, listener *GobootLifecycleListene
 			server.Datasource.Host = "127.0.0.1"
 		}
 		if server.Datasource.Driver == "mysql" {
+			if server.Datasource.Port == 0 {
+				server.Datasource.Port = 3306
+			}
 			url := server.Datasource.Url
 			if url == "" {
 				// user:password@tcp(localhost:5555)/dbname?tls=skip-verify&autocommit=true
@@ -764,65 +892,37 @@ func GetConfigApplication(config *GobootConfig, listener *GobootLifecycleListene
 	LogInfo("goboot before use.")
 	invokeListeners(boot, boot.Listeners.OnBeforeUse)
 
-	// 配置跨域
-	if server.Cors.Enable {
-		LogInfo("goboot enable cors.")
-		corsConfig := cors.Config{}
-
-		corsConfig.AllowAllOrigins = server.Cors.AllowAllOrigins
-		if !corsConfig.AllowAllOrigins {
-			if len(server.Cors.AllowOrigins) > 0 {
-				corsConfig.AllowOrigins = server.Cors.AllowOrigins
-			}
-			if len(server.Cors.AllowMethods) > 0 {
-				corsConfig.AllowMethods = server.Cors.AllowMethods
-			}
-			if len(server.Cors.AllowHeaders) > 0 {
-				corsConfig.AllowHeaders = server.Cors.AllowHeaders
-			}
-		}
+	// 配置请求追踪和访问日志
+	engine.Use(TraceMiddleware(server.Trace))
 
-		if len(server.Cors.ExposeHeaders) > 0 {
-			corsConfig.ExposeHeaders = server.Cors.ExposeHeaders
-		}
-		corsConfig.AllowCredentials = server.Cors.AllowCredentials
-		if server.Cors.MaxAgeMinutes > 0 {
-			corsConfig.MaxAge = time.Minute * time.Duration(server.Cors.MaxAgeMinutes)
-		}
-
-		engine.Use(cors.New(corsConfig))
+	// 配置指标
+	if server.Metrics.Enable {
+		boot.registerBuiltinMetrics(server.Metrics)
+		engine.Use(boot.metricsMiddleware())
 	}
 
-	// 配置gzip
-	if server.Gzip.Enable {
-		LogInfo("goboot enable gzip.")
-		gzipLevel := gzip.DefaultCompression
-		levelStr := server.Gzip.Level
-		if levelStr == "BestCompression" {
-			gzipLevel = gzip.BestCompression
-		} else if levelStr == "BestSpeed" {
-			gzipLevel = gzip.BestSpeed
-		} else if levelStr == "DefaultCompression" {
-			gzipLevel = gzip.DefaultCompression
-		} else if levelStr == "NoCompression" {
-			gzipLevel = gzip.NoCompression
-		}
-		options := []gzip.Option{}
-		if len(server.Gzip.ExcludeExtensions) > 0 {
-			op := gzip.WithExcludedExtensions(server.Gzip.ExcludeExtensions)
-			options = append(options, op)
-		}
-		if len(server.Gzip.ExcludePaths) > 0 {
-			op := gzip.WithExcludedPaths(server.Gzip.ExcludePaths)
-			options = append(options, op)
+	// 配置异常处理，panic 和错误交给错误处理器链
+	engine.Use(boot.errorMiddleware)
+	// 路径存在但请求方式不匹配时响应405，在 Run 时存在路由才开启
+	engine.NoMethod(boot.methodNotAllowedHandler)
+
+	// 配置管理端点和指标，管理端点未配置单独端口时使用应用端口
+	if !boot.useManagementServer() {
+		if server.Management.Enable {
+			boot.registerManagementEndpoints(engine, server.Management)
 		}
-		if len(server.Gzip.ExcludePathRegexes) > 0 {
-			op := gzip.WithExcludedPathsRegexs(server.Gzip.ExcludePathRegexes)
-			options = append(options, op)
+		if server.Metrics.Enable {
+			boot.registerMetricsEndpoint(engine, server)
+			LogInfo("goboot enable metrics at %v", metricsPath(server.Metrics))
 		}
-		engine.Use(gzip.Gzip(gzipLevel, options...))
 	}
 
+	// 配置跨域，支持运行时重新加载
+	engine.Use(boot.runtimeHandler("cors", CorsMiddleware(server.Cors)))
+
+	// 配置gzip，支持运行时重新加载
+	engine.Use(boot.runtimeHandler("gzip", GzipMiddleware(server.Gzip)))
+
 	// 配置 session
 	if server.Session.Enable {
 		LogInfo("goboot enable session.")
@@ -856,47 +956,38 @@ func GetConfigApplication(config *GobootConfig, listener *GobootLifecycleListene
 		}
 	}
 
+	// 配置限流，放在认证之前，使得登录等接口同样受到保护
+	if server.RateLimit.Enable {
+		boot.RateLimitStore = boot.newRateLimitStore(server.RateLimit)
+		boot.rateLimiter = newRateLimiter(server.RateLimit)
+	}
+	engine.Use(boot.rateLimitMiddleware(server.RateLimit))
+
+	// 配置令牌认证，放在映射、控制器、文件服务和代理之前
+	if server.Auth.Enable {
+		boot.TokenStore = boot.newTokenStore(server.Auth)
+	}
+	engine.Use(boot.authMiddleware(server.Auth))
+
+	// 配置JWT认证
+	if server.Jwt.Enable {
+		manager, err := NewJwtManager(server.Jwt, boot.Redis)
+		if err != nil {
+			LogError("goboot jwt config error of %v", err)
+			boot.startupErrors = append(boot.startupErrors, fmt.Sprintf("goboot.server.jwt: %v", err))
+		}
+		boot.JwtManager = manager
+	}
+	engine.Use(boot.jwtMiddleware(server.Jwt))
+
 	LogInfo("goboot before static resources.")
 	invokeListeners(boot, boot.Listeners.OnBeforeStaticResources)
 
-	// 配置静态资源
-	if server.StaticResources.Enable {
-		for _, staticItem := range server.StaticResources.Items {
-			LogInfo("goboot enable static resources, mapping: %v --> %v", staticItem.UrlPath, staticItem.FilePath)
-			if _, err := os.Stat(staticItem.FilePath); os.IsNotExist(err) {
-				os.MkdirAll(staticItem.FilePath, 0777)
-			}
-			engine.Static(staticItem.UrlPath, staticItem.FilePath)
-		}
-		// 处理404时的资源的try files处理
-		engine.NoRoute(func(c *gin.Context) {
-			reqPath := c.Request.URL.Path
-			if !strings.HasSuffix(reqPath, "/") {
-				reqPath = reqPath + "/"
-			}
-			for _, staticItem := range server.StaticResources.Items {
-				urlPath := staticItem.UrlPath
-				if !strings.HasSuffix(urlPath, "/") {
-					urlPath = urlPath + "/"
-				}
-				if !strings.HasPrefix(reqPath, urlPath) {
-					continue
-				}
-				filesArr := strings.Split(staticItem.TryFiles, " ")
-				for _, fileItem := range filesArr {
-					if fileItem == "" {
-						continue
-					}
-					tryFile := staticItem.FilePath + "/" + fileItem
-					_, err := os.Stat(tryFile)
-					if err == nil {
-						LogInfo("[try files] url: %v try to %v", reqPath, urlPath+fileItem)
-						c.File(tryFile)
-					}
-				}
-			}
-		})
-	}
+	// 配置静态资源，支持运行时重新加载
+	// 静态资源中间件放在最后，使得映射和代理优先于静态资源
+	staticHandler := boot.runtimeHandler("static", StaticResourcesMiddleware(server.StaticResources))
+	// 处理404时，先按函数名匹配映射，再处理资源的try files，都不匹配时响应404
+	engine.NoRoute(boot.mappingNoRouteHandler, boot.runtimeHandler("staticTryFiles", StaticTryFilesHandler(server.StaticResources)), boot.notFoundHandler)
 
 	LogInfo("goboot before templates resources.")
 	invokeListeners(boot, boot.Listeners.OnBeforeTemplatesResources)
@@ -913,35 +1004,21 @@ func GetConfigApplication(config *GobootConfig, listener *GobootLifecycleListene
 
 	LogInfo("goboot before file-server.")
 
-	// 配置文件服务器
-	if server.FileServer.Enable {
-		LogInfo("goboot enable file-server at rootPath: %v", server.FileServer.RootPath)
-		engine.Use(FileServerMiddleware(server.FileServer))
-	}
+	// 配置文件服务器，支持运行时重新加载
+	engine.Use(boot.runtimeHandler("fileServer", FileServerMiddleware(server.FileServer)))
 
 	LogInfo("goboot before proxy.")
 	invokeListeners(boot, boot.Listeners.OnBeforeProxy)
 
-	// 配置代理
-	if server.Proxy.Enable {
-		LogInfo("goboot enable %v proxy(s)", len(server.Proxy.Items))
-		for _, item := range server.Proxy.Items {
-			LogInfo("goboot proxy, path: %v", item)
-		}
-		engine.Use(ProxyMiddleware(server.Proxy))
-	}
+	// 配置代理，支持运行时重新加载
+	engine.Use(boot.runtimeHandler("proxy", ProxyMiddleware(server.Proxy)))
 
 	LogInfo("goboot before mapping.")
 	invokeListeners(boot, boot.Listeners.OnBeforeMapping)
 
-	// 配置自动映射
-	if server.Mapping.Enable {
-		LogInfo("goboot enbale %v mapping(s)", len(server.Mapping.Items))
-		for _, item := range server.Mapping.Items {
-			LogInfo("goboot mapping, path: %v", item)
-		}
-		engine.Use(MappingMiddleware(server.Mapping, boot))
-	}
+	// 自动映射和控制器在 Run 时编译为gin路由
+
+	engine.Use(staticHandler)
 
 	LogInfo("goboot prepared.")
 	invokeListeners(boot, boot.Listeners.OnPrepared)
@@ -949,219 +1026,286 @@ func GetConfigApplication(config *GobootConfig, listener *GobootLifecycleListene
 	return boot
 }
 
+// 跨域中间件
+func CorsMiddleware(config Cors) gin.HandlerFunc {
+	if !config.Enable {
+		return NextHandler
+	}
+	LogInfo("goboot enable cors.")
+	corsConfig := cors.Config{}
+
+	corsConfig.AllowAllOrigins = config.AllowAllOrigins
+	if !corsConfig.AllowAllOrigins {
+		if len(config.AllowOrigins) > 0 {
+			corsConfig.AllowOrigins = config.AllowOrigins
+		}
+		if len(config.AllowMethods) > 0 {
+			corsConfig.AllowMethods = config.AllowMethods
+		}
+		if len(config.AllowHeaders) > 0 {
+			corsConfig.AllowHeaders = config.AllowHeaders
+		}
+	}
+
+	if len(config.ExposeHeaders) > 0 {
+		corsConfig.ExposeHeaders = config.ExposeHeaders
+	}
+	corsConfig.AllowCredentials = config.AllowCredentials
+	if config.MaxAgeMinutes > 0 {
+		corsConfig.MaxAge = time.Minute * time.Duration(config.MaxAgeMinutes)
+	}
+
+	return cors.New(corsConfig)
+}
+
+// gzip中间件
+func GzipMiddleware(config Gzip) gin.HandlerFunc {
+	if !config.Enable {
+		return NextHandler
+	}
+	LogInfo("goboot enable gzip.")
+	gzipLevel := gzip.DefaultCompression
+	levelStr := config.Level
+	if levelStr == "BestCompression" {
+		gzipLevel = gzip.BestCompression
+	} else if levelStr == "BestSpeed" {
+		gzipLevel = gzip.BestSpeed
+	} else if levelStr == "DefaultCompression" {
+		gzipLevel = gzip.DefaultCompression
+	} else if levelStr == "NoCompression" {
+		gzipLevel = gzip.NoCompression
+	}
+	options := []gzip.Option{}
+	if len(config.ExcludeExtensions) > 0 {
+		op := gzip.WithExcludedExtensions(config.ExcludeExtensions)
+		options = append(options, op)
+	}
+	if len(config.ExcludePaths) > 0 {
+		op := gzip.WithExcludedPaths(config.ExcludePaths)
+		options = append(options, op)
+	}
+	if len(config.ExcludePathRegexes) > 0 {
+		op := gzip.WithExcludedPathsRegexs(config.ExcludePathRegexes)
+		options = append(options, op)
+	}
+	return gzip.Gzip(gzipLevel, options...)
+}
+
+// 静态资源中间件
+// GET/HEAD 请求匹配到存在的文件时直接响应，否则继续执行
+func StaticResourcesMiddleware(static StaticResources) gin.HandlerFunc {
+	if !static.Enable {
+		return NextHandler
+	}
+	for _, staticItem := range static.Items {
+		LogInfo("goboot enable static resources, mapping: %v --> %v", staticItem.UrlPath, staticItem.FilePath)
+		if _, err := os.Stat(staticItem.FilePath); os.IsNotExist(err) {
+			os.MkdirAll(staticItem.FilePath, 0777)
+		}
+	}
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		reqPath := c.Request.URL.Path
+		for _, staticItem := range static.Items {
+			urlPath := strings.TrimSuffix(staticItem.UrlPath, "/")
+			if reqPath != urlPath && !strings.HasPrefix(reqPath, urlPath+"/") {
+				continue
+			}
+			filePath := filepath.Join(staticItem.FilePath, filepath.FromSlash(path.Clean("/"+reqPath[len(urlPath):])))
+			info, err := os.Stat(filePath)
+			if err != nil {
+				continue
+			}
+			if info.IsDir() {
+				// 目录访问index.html，与gin的Static保持一致
+				if !strings.HasSuffix(reqPath, "/") {
+					c.Redirect(http.StatusMovedPermanently, reqPath+"/")
+					c.Abort()
+					return
+				}
+				filePath = filepath.Join(filePath, "index.html")
+				if _, err := os.Stat(filePath); err != nil {
+					continue
+				}
+			}
+			c.File(filePath)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// 静态资源的try files处理，用于404时
+func StaticTryFilesHandler(static StaticResources) gin.HandlerFunc {
+	if !static.Enable {
+		return NextHandler
+	}
+	return func(c *gin.Context) {
+		reqPath := c.Request.URL.Path
+		if !strings.HasSuffix(reqPath, "/") {
+			reqPath = reqPath + "/"
+		}
+		for _, staticItem := range static.Items {
+			urlPath := staticItem.UrlPath
+			if !strings.HasSuffix(urlPath, "/") {
+				urlPath = urlPath + "/"
+			}
+			if !strings.HasPrefix(reqPath, urlPath) {
+				continue
+			}
+			filesArr := strings.Split(staticItem.TryFiles, " ")
+			for _, fileItem := range filesArr {
+				if fileItem == "" {
+					continue
+				}
+				tryFile := staticItem.FilePath + "/" + fileItem
+				_, err := os.Stat(tryFile)
+				if err == nil {
+					LogInfo("[try files] url: %v try to %v", reqPath, urlPath+fileItem)
+					c.File(tryFile)
+					return
+				}
+			}
+		}
+	}
+}
+
+// 直接继续执行的中间件，用于未开启的功能
+func NextHandler(c *gin.Context) {
+	c.Next()
+}
+
 // 映射请求中间件
+// 映射的函数在 Run 时注册为gin路由，这里按函数名处理其他写法的路径
+// 例如 /api/helloWorld, /api/Hello_World 都会映射到 HelloWorld 函数
 func MappingMiddleware(mapping Mapping, boot *GobootApplication) gin.HandlerFunc {
+	if !mapping.Enable {
+		return NextHandler
+	}
 	return func(c *gin.Context) {
-		hasMatched := false
 		// 检查路径前缀匹配
 		urlPath := c.Request.URL.Path
 		for _, item := range mapping.Items {
-			if strings.HasPrefix(urlPath, item) {
-				hasMatched = true
-				proxyPath := urlPath[len(item):]
-				LogInfo("goboot mapping, path: %v", item)
-				MappingHandler(boot, c, proxyPath, boot.Handlers...)
+			if strings.HasPrefix(urlPath, item) && boot.handleMappingByName(c, item, urlPath[len(item):]) {
+				c.Abort()
+				return
 			}
 		}
-		if !hasMatched {
-			// 如果不匹配，继续执行
-			c.Next()
-		}
-
+		// 如果不匹配，继续执行
+		c.Next()
 	}
 }
 
 // GET,PUT,POST,DELETE,PATCH
 // XG_,XU_,XP_,XD_,XH_,XA_
 // 处理mapping自动映射
-// 将配置中的mapping自动按照URL路径映射
+// 在指定的处理器中按照函数名查找并调用，每次调用都会使用反射查找
+// mapping 和 controller 使用 Run 时编译的路由表，不经过这个函数
 func MappingHandler(boot *GobootApplication, c *gin.Context, proxyPath string, handlers ...interface{}) {
-	// 处理自动映射的异常为404
-	errorMsg := "request not found."
-	defer func() {
-		err := recover()
-		if err != nil {
-			c.String(404, errorMsg)
-		}
-	}()
-
 	if len(handlers) == 0 {
-		errorMsg = "not found any handlers."
-		panic(errorMsg)
+		boot.HandleError(c, NewStatusError(404, "not found any handlers."))
+		return
 	}
 
+	methodName := MappingMethodName(proxyPath)
 	requestMethod := c.Request.Method
 
-	// 将路径转换为函数名
-	paths := strings.Split(proxyPath, "/")
-	methodName := ""
-
-	for _, path := range paths {
-		item := strings.Trim(path, " \t\n\r")
-		if item == "" {
+	// 遍历处理器，查找符合映射规则的函数
+	for _, handler := range handlers {
+		routes, err := mappingHandlerRoutes(boot, handler)
+		if err != nil {
 			continue
 		}
-		parts := strings.Split(item, "-")
-		item = ""
-		for _, part := range parts {
-			if part == "" {
+		for _, route := range routes {
+			if route.Name != methodName {
 				continue
 			}
-			item += strings.ToUpper(part[:1]) + part[1:]
-		}
-		if item == "" {
-			continue
-		}
-
-		item = strings.ToUpper(item[:1]) + item[1:]
-		if methodName != "" {
-			methodName += "_"
-		}
-		methodName += item
-	}
-
-	// 遍历处理器，查找符合映射规则的函数
-	for _, handler := range handlers {
-		// 类型
-		htype := reflect.TypeOf(handler)
-		// 实际类型
-		rtype := htype
-		// 当时指针类型时，需要拿到实际类型
-		if htype.Kind() == reflect.Ptr && htype.Elem().Kind() == reflect.Struct {
-			rtype = htype.Elem()
-		}
-		// 只有是结构体类型的处理器才算处理器
-		if rtype.Kind() == reflect.Struct {
-			// 得到函数个数
-			mcnt := htype.NumMethod()
-			for i := 0; i < mcnt; i++ {
-				mm := htype.Method(i)
-				funcName := mm.Name
-				funcMethod := ""
-				if strings.HasPrefix(funcName, "XG_") {
-					funcMethod = "GET"
-					funcName = funcName[3:]
-				} else if strings.HasPrefix(funcName, "XP_") {
-					funcMethod = "POST"
-					funcName = funcName[3:]
-				} else if strings.HasPrefix(funcName, "XU_") {
-					funcMethod = "PUT"
-					funcName = funcName[3:]
-				} else if strings.HasPrefix(funcName, "XD_") {
-					funcMethod = "DELETE"
-					funcName = funcName[3:]
-				} else if strings.HasPrefix(funcName, "XH_") {
-					funcMethod = "PATCH"
-					funcName = funcName[3:]
-				} else if strings.HasPrefix(funcName, "XA_") {
-					funcMethod = ""
-					funcName = funcName[3:]
-				}
-				// 如果函数名匹配
-				if funcName == methodName {
-					if funcMethod != "" {
-						if funcMethod != requestMethod {
-							errorMsg = "request method allow, require only " + funcMethod
-							panic(errorMsg)
-						}
-					}
-					// 拿到函数对象
-					method := reflect.ValueOf(handler).MethodByName(mm.Name)
-					// 获取入参个数
-					paramCnt := method.Type().NumIn()
-					callArgs := []reflect.Value{}
-					matchFlag := true
-					// 为每个函数入参注入值
-					for p := 0; p < paramCnt; p++ {
-						arg := method.Type().In(p)
-						val, ok := HandleMappingMethodArg(arg, boot, c)
-						if ok {
-							callArgs = append(callArgs, val)
-						} else {
-							matchFlag = false
-							break
-						}
-					}
-					// 如果出现无法注入的参数，则不匹配，进行继续匹配
-					if !matchFlag {
-						continue
-					}
-					// 匹配成功的函数，进行调用
-					method.Call(callArgs)
-					return
-				}
+			if route.Method != MappingMethodAny && route.Method != requestMethod {
+				boot.methodNotAllowed(c, []string{route.Method})
+				return
 			}
-
+			boot.invokeMappingRoute(c, route)
+			return
 		}
 	}
 
 	// 执行到这里，说明没有任何函数匹配
-	errorMsg = "not found any handler method in handlers"
-	panic(errorMsg)
+	boot.HandleError(c, NewStatusError(404, "not found any handler method in handlers"))
 }
 
 // 为自动映射的方法添加调用参数
 // 实现对boot，ctx,engine,request的方法入参自动注入
 // 对结构体的请求参数自动填充能力
+// 请求参数绑定或校验失败时返回false
 func HandleMappingMethodArg(arg reflect.Type, boot *GobootApplication, c *gin.Context) (reflect.Value, bool) {
-	engine := boot.App
-	request := c.Request
-	resp := ApiOk(nil)
-	ctxResp := &CtxResp{
-		Context: c,
-		Session: nil,
-		App:     boot,
-	}
-	redis := boot.Redis.Redis
-	redisCli := boot.Redis
-	gormDb := boot.GormDb
-	if boot.Config.Goboot.Server.Session.Enable {
-		ctxResp.Session = sessions.Default(c)
-		if arg.String() == "sessions.Session" {
-			return reflect.ValueOf(ctxResp.Session), true
-		}
-	}
-
-	// 如果是指针类型的参数
-	if arg.Kind() == reflect.Ptr {
-		// 分别判断是否是支持注入的内置类型，如果是，直接注入
-		if arg.Elem() == reflect.TypeOf(*c) {
-			return reflect.ValueOf(c), true
-		} else if arg.Elem() == reflect.TypeOf(*ctxResp) {
-			return reflect.ValueOf(ctxResp), true
-		} else if arg.Elem() == reflect.TypeOf(*resp) {
-			return reflect.ValueOf(resp), true
-		} else if arg.Elem() == reflect.TypeOf(*request) {
-			return reflect.ValueOf(request), true
-		} else if arg.Elem() == reflect.TypeOf(*boot) {
-			return reflect.ValueOf(boot), true
-		} else if arg.Elem() == reflect.TypeOf(*engine) {
-			return reflect.ValueOf(engine), true
-		} else if arg.Elem() == reflect.TypeOf(*redisCli) {
-			return reflect.ValueOf(redisCli), true
-		} else if arg.Elem() == reflect.TypeOf(*gormDb) {
-			return reflect.ValueOf(gormDb), true
-		} else if arg.Elem() == reflect.TypeOf(*redis) {
-			return reflect.ValueOf(redis), true
-		} else if arg.Elem().Kind() == reflect.Struct {
-			// 如果不是预定义的，但是是结构体，则自动请求参数绑定注入
-			bindParam := reflect.New(arg.Elem()).Interface()
-			c.ShouldBind(bindParam)
-			return reflect.ValueOf(bindParam), true
+	val, ok, err := resolveMappingMethodArg(arg, boot, c)
+	return val, ok && err == nil
+}
+
+// 为自动映射的方法添加调用参数
+// 第二个返回值表示是否支持这个类型，请求参数绑定或校验失败时返回 *BindError
+func resolveMappingMethodArg(arg reflect.Type, boot *GobootApplication, c *gin.Context) (reflect.Value, bool, error) {
+	// 绑定的自定义配置
+	if bean, ok := boot.ConfigBeans[arg]; ok {
+		return reflect.ValueOf(bean), true, nil
+	}
+	if bean, ok := boot.ConfigBeans[reflect.PtrTo(arg)]; ok {
+		return reflect.ValueOf(bean).Elem(), true, nil
+	}
+
+	// 容器中的服务
+	if boot.hasService(arg) {
+		val, err := boot.resolveDependency(c, arg, nil)
+		return val, true, err
+	}
+
+	// 注册的和内置的注入器
+	if injector, ok := boot.argInjector(arg); ok {
+		val, err := boot.injectMappingArg(c, arg, injector)
+		return val, true, err
+	}
+
+	// 指定来源的包装类型，例如 Header[T]
+	if isMappingSourceArg(arg) {
+		if arg.Kind() == reflect.Ptr {
+			bindParam := reflect.New(arg.Elem())
+			err := bindParam.Interface().(mappingSourceBinder).bindMappingSource(boot, c)
+			return bindParam, true, err
 		}
+		bindParam := reflect.New(arg)
+		err := bindParam.Interface().(mappingSourceBinder).bindMappingSource(boot, c)
+		return bindParam.Elem(), true, err
+	}
+
+	if arg.Kind() == reflect.Ptr && arg.Elem().Kind() == reflect.Struct {
+		// 如果不是预定义的，但是是结构体，则自动请求参数绑定注入
+		bindParam := reflect.New(arg.Elem()).Interface()
+		err := boot.BindMappingParam(c, bindParam)
+		return reflect.ValueOf(bindParam), true, err
 	} else if arg.Kind() == reflect.Struct {
 		// 如果直接是结构体类型，直接实例化，自动请求参数绑定注入
 		bindParam := reflect.New(arg).Interface()
-		c.ShouldBind(bindParam)
-		return reflect.ValueOf(bindParam).Elem(), true
+		err := boot.BindMappingParam(c, bindParam)
+		return reflect.ValueOf(bindParam).Elem(), true, err
 	}
 
 	// 其他类型，则绑定参数失败
-	return reflect.ValueOf(false), false
+	return reflect.ValueOf(false), false, nil
 }
 
 // 代理请求中间件
 func ProxyMiddleware(proxy Proxy) gin.HandlerFunc {
+	if !proxy.Enable {
+		return NextHandler
+	}
+	LogInfo("goboot enable %v proxy(s)", len(proxy.Items))
+	for _, item := range proxy.Items {
+		LogInfo("goboot proxy, path: %v", item)
+	}
 	return func(c *gin.Context) {
 		hasMatched := false
 		// 检查路径前缀匹配
@@ -1172,13 +1316,17 @@ func ProxyMiddleware(proxy Proxy) gin.HandlerFunc {
 				redirect := item.Redirect
 				proxyPath := urlPath[len(item.Path):]
 				LogInfo("goboot proxy, path: %v", item)
+				c.Set(ContextProxyNameKey, item.Name)
 				ProxyHandler(c, redirect, proxyPath)
 			}
 		}
 		if !hasMatched {
 			// 如果不匹配，继续执行
 			c.Next()
+			return
 		}
+		// 已经代理的请求，不再执行后续的路由
+		c.Abort()
 
 	}
 }
@@ -1201,14 +1349,26 @@ func ProxyHandler(c *gin.Context, redirect string, proxyPath string) {
 		req.URL.Scheme = remote.Scheme
 		req.URL.Host = remote.Host
 		req.URL.Path = path.Join(remote.Path, proxyPath)
-		LogInfo("proxy req: %v %v", req.Method, req.URL)
+		LogInfo("proxy req: %v %v, request id: %v", req.Method, req.URL, GetRequestId(c))
 	}
 	client.ModifyResponse = func(resp *http.Response) error {
 		LogInfo("proxy resp: %v | %v | %v", resp.StatusCode, resp.Status, resp.Request.URL)
+		// 上游返回的请求ID与当前请求相同，避免响应头重复
+		if header := c.GetString(contextRequestIdHeaderKey); header != "" {
+			resp.Header.Del(header)
+		}
 		return nil
 	}
+	failed := false
+	client.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		failed = true
+		LogError("proxy error: %v | %v", req.URL, err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
 
+	start := time.Now()
 	client.ServeHTTP(c.Writer, c.Request)
+	recordProxyMetrics(c, start, failed)
 }
 
 // 文件服务配置
@@ -1333,8 +1493,8 @@ func SliceContains(list []string, elem string) bool {
 	return false
 }
 
-func FileServerMiddleware(server FileServer) gin.HandlerFunc {
-
+// 获取文件服务的路径前缀，默认 /file-server，根路径时为空
+func fileServerBasePath(server FileServer) string {
 	pathBase := server.UrlPath
 	if pathBase == "" {
 		pathBase = "/file-server"
@@ -1347,6 +1507,16 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 	if pathBase == "/" {
 		pathBase = ""
 	}
+	return pathBase
+}
+
+func FileServerMiddleware(server FileServer) gin.HandlerFunc {
+	if !server.Enable {
+		return NextHandler
+	}
+	LogInfo("goboot enable file-server at rootPath: %v", server.RootPath)
+
+	pathBase := fileServerBasePath(server)
 	pathList := pathBase + "/list"
 	pathUpload := pathBase + "/upload"
 	pathDownload := pathBase + "/download"
@@ -1368,10 +1538,11 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 			LogInfo("file-server enabled download api: GET %v/{subPath}[?type=inline], such GET %v/video/dog/dog.mp4 to download file, GET %v/video/dog/dog.mp4?type=inline to preview in browser", pathDownload, pathDownload, pathDownload)
 		}
 	}
-	return func(c *gin.Context) {
+	// 文件服务处理请求，未匹配时设置 passed
+	handle := func(c *gin.Context, passed *bool) {
 		// 如果未开启文件服务，直接跳过
 		if !server.Enable {
-			c.Next()
+			*passed = true
 			return
 		}
 		// 检查路径前缀匹配
@@ -1392,27 +1563,27 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 			allowedPath, _ := filepath.Abs(rootPath)
 			absPath, _ := filepath.Abs(fullPath)
 			if !strings.HasPrefix(absPath, allowedPath) {
-				c.JSON(500, ApiError(500, filePath+" not allow access!"))
+				c.JSON(500, ApiError(500, filePath+" not allow access!").WithRequestId(c))
 				return
 			}
 
 			// 检查文件是否存在
 			info, err := os.Stat(fullPath)
 			if os.IsNotExist(err) {
-				c.JSON(404, ApiError(500, filePath+" not exists!"))
+				c.JSON(404, ApiError(500, filePath+" not exists!").WithRequestId(c))
 				return
 			}
 
 			// 检查是否是目录
 			if !info.IsDir() {
-				c.JSON(500, ApiError(500, filePath+" is not directory!"))
+				c.JSON(500, ApiError(500, filePath+" is not directory!").WithRequestId(c))
 				return
 			}
 
 			files, err := ListFiles(fullPath, rootPath)
 
 			if err != nil {
-				c.JSON(500, ApiError(500, filePath+" list error!"))
+				c.JSON(500, ApiError(500, filePath+" list error!").WithRequestId(c))
 				return
 			}
 
@@ -1971,27 +2142,27 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 			allowedPath, _ := filepath.Abs(rootPath)
 			absPath, _ := filepath.Abs(fullPath)
 			if !strings.HasPrefix(absPath, allowedPath) {
-				c.JSON(200, ApiError(500, filePath+" not allow access!"))
+				c.JSON(200, ApiError(500, filePath+" not allow access!").WithRequestId(c))
 				return
 			}
 
 			// 检查文件是否存在
 			info, err := os.Stat(fullPath)
 			if os.IsNotExist(err) {
-				c.JSON(200, ApiError(500, filePath+" not exists!"))
+				c.JSON(200, ApiError(500, filePath+" not exists!").WithRequestId(c))
 				return
 			}
 
 			// 检查是否是目录
 			if !info.IsDir() {
-				c.JSON(200, ApiError(500, filePath+" is not directory!"))
+				c.JSON(200, ApiError(500, filePath+" is not directory!").WithRequestId(c))
 				return
 			}
 
 			files, err := ListFiles(fullPath, rootPath)
 
 			if err != nil {
-				c.JSON(200, ApiError(500, filePath+" list error!"))
+				c.JSON(200, ApiError(500, filePath+" list error!").WithRequestId(c))
 				return
 			}
 
@@ -2007,20 +2178,20 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 			allowedPath, _ := filepath.Abs(rootPath)
 			absPath, _ := filepath.Abs(fullPath)
 			if !strings.HasPrefix(absPath, allowedPath) {
-				c.JSON(500, ApiError(500, filePath+" not allow access!"))
+				c.JSON(500, ApiError(500, filePath+" not allow access!").WithRequestId(c))
 				return
 			}
 
 			// 检查文件是否存在
 			info, err := os.Stat(fullPath)
 			if os.IsNotExist(err) {
-				c.JSON(404, ApiError(500, filePath+" not exists!"))
+				c.JSON(404, ApiError(500, filePath+" not exists!").WithRequestId(c))
 				return
 			}
 
 			// 检查是否是目录
 			if !info.IsDir() {
-				c.JSON(500, ApiError(500, filePath+" is directory!"))
+				c.JSON(500, ApiError(500, filePath+" is directory!").WithRequestId(c))
 				return
 			}
 
@@ -2028,6 +2199,9 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 
 			savePath := filepath.Join(fullPath, file.Filename)
 			err = c.SaveUploadedFile(file, savePath)
+			if err == nil {
+				recordFileServerBytes(c, "upload", file.Size)
+			}
 
 			relPath, _ := filepath.Rel(rootPath, savePath)
 			c.JSON(200, ApiOk(relPath))
@@ -2036,6 +2210,11 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 		if !server.DisableDownload && strings.HasPrefix(urlPath, pathDownload) {
 			filePath := urlPath[len(pathDownload):]
 			LogInfo("goboot file-server, download path: %v", filePath)
+			defer func() {
+				if c.Writer.Status() == http.StatusOK || c.Writer.Status() == http.StatusPartialContent {
+					recordFileServerBytes(c, "download", int64(c.Writer.Size()))
+				}
+			}()
 
 			fullPath := filepath.Join(rootPath, filePath)
 
@@ -2043,7 +2222,7 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 			allowedPath, _ := filepath.Abs(rootPath)
 			absPath, _ := filepath.Abs(fullPath)
 			if !strings.HasPrefix(absPath, allowedPath) {
-				c.JSON(500, ApiError(500, filePath+" not allow access!"))
+				c.JSON(500, ApiError(500, filePath+" not allow access!").WithRequestId(c))
 				return
 			}
 
@@ -2076,20 +2255,20 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 			// 检查文件是否存在
 			info, err := os.Stat(fullPath)
 			if os.IsNotExist(err) {
-				c.JSON(404, ApiError(500, filePath+" not exists!"))
+				c.JSON(404, ApiError(500, filePath+" not exists!").WithRequestId(c))
 				return
 			}
 
 			// 检查是否是目录
 			if info.IsDir() {
-				c.JSON(500, ApiError(500, filePath+" is directory!"))
+				c.JSON(500, ApiError(500, filePath+" is directory!").WithRequestId(c))
 				return
 			}
 
 			// 打开文件
 			file, err := os.Open(fullPath)
 			if err != nil {
-				c.JSON(500, ApiError(500, filePath+" open file error!"))
+				c.JSON(500, ApiError(500, filePath+" open file error!").WithRequestId(c))
 				return
 			}
 			defer file.Close()
@@ -2143,7 +2322,7 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 					c.Header("Content-Length", strconv.FormatInt(info.Size(), 10))
 					_, err = io.Copy(c.Writer, file)
 					if err != nil {
-						c.JSON(500, ApiError(500, filePath+" send file error!"))
+						c.JSON(500, ApiError(500, filePath+" send file error!").WithRequestId(c))
 						return
 					}
 					return
@@ -2196,7 +2375,7 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 						sectionReader := io.NewSectionReader(file, start, end-start+1)
 						_, err = io.Copy(c.Writer, sectionReader)
 						if err != nil {
-							c.JSON(500, ApiError(500, filePath+" send section error!"))
+							c.JSON(500, ApiError(500, filePath+" send section error!").WithRequestId(c))
 							return
 						}
 						return
@@ -2209,15 +2388,24 @@ func FileServerMiddleware(server FileServer) gin.HandlerFunc {
 			c.Header("Content-Length", strconv.FormatInt(info.Size(), 10))
 			_, err = io.Copy(c.Writer, file)
 			if err != nil {
-				c.JSON(500, ApiError(500, filePath+" send file error!"))
+				c.JSON(500, ApiError(500, filePath+" send file error!").WithRequestId(c))
 				return
 			}
 
 			return
 		}
 		// 如果不匹配，继续执行
-		c.Next()
-
+		*passed = true
+	}
+	return func(c *gin.Context) {
+		passed := false
+		handle(c, &passed)
+		if passed {
+			c.Next()
+			return
+		}
+		// 已经处理的请求，不再执行后续的路由
+		c.Abort()
 	}
 }
 
@@ -2546,19 +2734,29 @@ func (boot *GobootApplication) Run() {
 
 	LogInfo("goboot run ...")
 
-	// 配置控制器
-	if len(boot.Controllers) > 0 {
-		LogInfo("goboot enbale %v controllers(s)", len(boot.Controllers))
-		for _, item := range boot.Controllers {
-			groupPath := item.Path()
-			groupRouters := engine.Group(groupPath)
-			{
-				groupRouters.Any("/*proxyPath", func(c *gin.Context) {
-					proxyPath := c.Param("proxyPath")
-					MappingHandler(boot, c, proxyPath, item)
-				})
-			}
+	// 创建服务并注入处理器和控制器的字段
+	boot.buildServices()
+
+	// 编译自动映射和控制器的路由
+	boot.compileMappings()
+
+	// 使用路由表提供OpenAPI文档
+	boot.registerOpenApi()
+
+	// 存在启动错误时，打印并退出
+	if len(boot.startupErrors) > 0 {
+		LogError("goboot startup failure, %v error(s):", len(boot.startupErrors))
+		for i, item := range boot.startupErrors {
+			LogError("  %v. %v", i+1, item)
 		}
+		os.Exit(1)
+	}
+
+	// 初始化服务
+	err := boot.initServices()
+	if err != nil {
+		LogError("%v", err)
+		os.Exit(1)
 	}
 
 	LogInfo("goboot brfore banner.")
@@ -2568,7 +2766,7 @@ func (boot *GobootApplication) Run() {
 		bytes, err := ioutil.ReadFile(server.BannerPath)
 		if err != nil {
 			LogInfo("goboot default banner.")
-			fmt.Println(DefaultBannerText)
+			fmt.Print(DefaultBannerText)
 		} else {
 			LogInfo("goboot read banner, file: %v", server.BannerPath)
 			fmt.Println(string(bytes))
@@ -2576,7 +2774,11 @@ func (boot *GobootApplication) Run() {
 	}
 
 	LogInfo("app [%v] on [%v] run at port [%v]", app.Name, profiles.Active, server.Port)
-	LogInfo("local: http://localhost:%v/", server.Port)
+	scheme := "http"
+	if server.Https.Enable {
+		scheme = "https"
+	}
+	LogInfo("local: %v://localhost:%v/", scheme, server.Port)
 
 	iters, err := net.Interfaces()
 	if err == nil {
@@ -2594,7 +2796,7 @@ func (boot *GobootApplication) Run() {
 					ipNet, ok := addr.(*net.IPNet)
 
 					if ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsMulticast() {
-						LogInfo("\thttp://%v:%v/", ipNet.IP, server.Port)
+						LogInfo("\t%v://%v:%v/", scheme, ipNet.IP, server.Port)
 					}
 				}
 			}
@@ -2616,13 +2818,154 @@ func (boot *GobootApplication) Run() {
 		}
 	}
 
+	// 路由全部注册后开启405响应
+	boot.enableMethodNotAllowed()
+
 	bindStr := fmt.Sprintf(":%v", server.Port)
+	boot.HttpServer = &http.Server{
+		Addr:    bindStr,
+		Handler: engine,
+	}
+
+	// 启动任何服务之前校验TLS配置，失败时释放资源并退出
 	if server.Https.Enable {
-		LogInfo("goboot run with https, pem: %v, key: %v", server.Https.PemPath, server.Https.KeyPath)
-		engine.Run(bindStr, server.Https.PemPath, server.Https.KeyPath)
-	} else {
-		LogInfo("goboot run with http.")
-		engine.Run(bindStr)
+		tlsConfig, err := ResolveTlsConfig(server.Https)
+		if err != nil {
+			LogError("goboot https config error of %v", err)
+			boot.exitAfterShutdown(1)
+		}
+		boot.HttpServer.TLSConfig = tlsConfig
+	}
+
+	// 在协程中启动服务，主协程等待停机信号
+	errChan := make(chan error, 3)
+	boot.startManagementServer(errChan)
+	if server.Https.Enable {
+		if server.Https.RedirectHttpPort > 0 {
+			redirectBindStr := fmt.Sprintf(":%v", server.Https.RedirectHttpPort)
+			boot.RedirectServer = &http.Server{
+				Addr:    redirectBindStr,
+				Handler: HttpsRedirectHandler(server.Port),
+			}
+			LogInfo("goboot redirect http port [%v] to https port [%v]", server.Https.RedirectHttpPort, server.Port)
+			go func() {
+				errChan <- boot.RedirectServer.ListenAndServe()
+			}()
+		}
+	}
+	go func() {
+		var err error
+		if server.Https.Enable {
+			LogInfo("goboot run with https, pem: %v, key: %v", server.Https.PemPath, server.Https.KeyPath)
+			err = boot.HttpServer.ListenAndServeTLS(server.Https.PemPath, server.Https.KeyPath)
+		} else {
+			LogInfo("goboot run with http.")
+			err = boot.HttpServer.ListenAndServe()
+		}
+		errChan <- err
+	}()
+
+	// 开启热加载时监听配置文件
+	boot.startConfigWatcher()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	// 监听失败时停机后以非0退出
+	exitCode := 0
+	select {
+	case sig := <-quit:
+		LogInfo("goboot receive signal %v, shutdown ...", sig)
+	case err := <-errChan:
+		if err != nil && err != http.ErrServerClosed {
+			LogError("goboot run error of %v", err)
+			exitCode = 1
+		}
+	}
+
+	boot.exitAfterShutdown(exitCode)
+}
+
+// 在停机等待时间内停止应用，退出码非0时退出进程
+func (boot *GobootApplication) exitAfterShutdown(exitCode int) {
+	timeout := boot.Config.Goboot.Server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	err := boot.Shutdown(ctx)
+	cancel()
+	if err != nil {
+		LogError("goboot shutdown error of %v", err)
 	}
+	LogInfo("goboot exited.")
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// 停止应用
+// 等待正在处理的请求完成，超时由ctx控制
+// 之后关闭redis、数据源等资源
+// 可以多次调用，只会执行一次
+func (boot *GobootApplication) Shutdown(ctx context.Context) error {
+	boot.shutdownOnce.Do(func() {
+		LogInfo("goboot before shutdown.")
+		invokeListeners(boot, boot.Listeners.OnBeforeShutdown)
+
+		boot.stopConfigWatcher()
 
+		var errs []string
+		if boot.HttpServer != nil {
+			LogInfo("goboot shutdown http server ...")
+			err := boot.HttpServer.Shutdown(ctx)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("http server: %v", err))
+			}
+		}
+		if boot.RedirectServer != nil {
+			err := boot.RedirectServer.Shutdown(ctx)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("redirect server: %v", err))
+			}
+		}
+		if boot.ManagementServer != nil {
+			err := boot.ManagementServer.Shutdown(ctx)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("management server: %v", err))
+			}
+		}
+
+		// 服务可能使用redis和数据源，先关闭服务
+		err := boot.closeServices()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+
+		if boot.Redis != nil && boot.Redis.Redis != nil {
+			LogInfo("goboot close redis.")
+			err := boot.Redis.Redis.Close()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("redis: %v", err))
+			}
+		}
+
+		// gorm 复用的是 Db 的连接，关闭 Db 即可
+		if boot.Db != nil {
+			LogInfo("goboot close datasource.")
+			err := boot.Db.Close()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("datasource: %v", err))
+			}
+		}
+
+		if len(errs) > 0 {
+			boot.shutdownErr = fmt.Errorf("goboot shutdown error: %v", strings.Join(errs, "; "))
+		}
+
+		LogInfo("goboot shutdown.")
+		invokeListeners(boot, boot.Listeners.OnShutdown)
+	})
+	return boot.shutdownErr
 }
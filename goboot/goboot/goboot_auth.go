@@ -0,0 +1,525 @@
+package goboot
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 令牌认证区
+// 开启 goboot.server.auth 后，使用 Tokens.FindToken 从请求头、查询参数、表单和路径变量中查找令牌
+// 令牌有效时，当前用户 *Principal 保存到上下文，映射函数可以直接注入 *goboot.Principal
+// 需要认证的路径没有有效令牌时响应401，作用于映射、控制器、代理和文件服务
+// 管理端点和指标使用自身的令牌，不受影响
+//
+// 路径模式以 /** 结尾时按照前缀匹配，否则使用 path.Match 匹配，例如 /api/**, /api/*/public
+// includes 为空时全部路径需要认证，excludes 优先于 includes
+//
+// 同时开启JWT时，两者的 includes/excludes 分别判断，任一方需要认证的路径都需要认证
+// 令牌认证只校验自身签发的令牌，没有令牌或者JWT格式的令牌交给JWT中间件校验
+// 需要认证的路径，有效的令牌或者有效的JWT都可以通过认证
+// 按函数名匹配其他写法的映射请求，例如 /api/Admin/DeleteUser，同时使用映射路由的路径 /api/admin/delete-user 判断
+//
+// 登录时调用 boot.Login 签发令牌，退出时调用 boot.Logout 删除令牌：
+//
+//	func (api *Api) XP_Login(boot *goboot.GobootApplication, c *gin.Context, req *LoginReq) (string, error) {
+//		user, err := api.check(req)
+//		if err != nil {
+//			return "", err
+//		}
+//		return boot.Login(c, &goboot.Principal{Id: user.Id, Name: user.Name, Roles: user.Roles})
+//	}
+//
+// /////////////////////////////////////////////////////////
+
+// 默认的令牌名称
+const DefaultAuthTokenName string = "Authorization"
+
+// 默认的令牌有效时间，单位秒
+const DefaultAuthExpire int = 7200
+
+// 默认的令牌数据库表
+const DefaultAuthTable string = "goboot_token"
+
+// 当前用户的上下文键
+const ContextPrincipalKey string = "goboot.principal"
+
+// 令牌认证需要认证、交给JWT中间件校验的上下文键
+const contextAuthRequiredKey string = "goboot.authRequired"
+
+// 令牌存储在redis中的键前缀
+const authRedisKeyPrefix string = "goboot:token:"
+
+// 令牌认证配置
+type Auth struct {
+	Enable    bool     `yaml:"enable"`
+	TokenName string   `yaml:"tokenName"` // 令牌名称，默认 Authorization，请求头中可以带 Bearer 前缀
+	Store     string   `yaml:"store"`     // 令牌存储，memory/redis/db，默认memory
+	Expire    int      `yaml:"expire"`    // 令牌有效时间，单位秒，默认7200
+	Sliding   bool     `yaml:"sliding"`   // 是否滑动续期，剩余时间不足一半时续期
+	Table     string   `yaml:"table"`     // 使用数据库存储时的表名，默认 goboot_token
+	Includes  []string `yaml:"includes"`  // 需要认证的路径模式，为空时全部路径需要认证
+	Excludes  []string `yaml:"excludes"`  // 不需要认证的路径模式，例如登录接口
+}
+
+// 认证的当前用户
+type Principal struct {
+	Id       string                 `json:"id"`
+	Name     string                 `json:"name"`
+	Roles    []string               `json:"roles,omitempty"`
+	Attrs    map[string]interface{} `json:"attrs,omitempty"`
+	ExpireAt time.Time              `json:"expireAt"`
+	Token    string                 `json:"-"`
+}
+
+// 是否拥有角色
+func (principal *Principal) HasRole(role string) bool {
+	for _, item := range principal.Roles {
+		if item == role {
+			return true
+		}
+	}
+	return false
+}
+
+// 令牌存储
+// Find 在令牌不存在或者过期时返回nil
+type TokenStore interface {
+	Save(token string, principal *Principal, expire time.Duration) error
+	Find(token string) (*Principal, error)
+	Remove(token string) error
+}
+
+// 获取当前用户，未认证时返回nil
+func GetPrincipal(c *gin.Context) *Principal {
+	value, ok := c.Get(ContextPrincipalKey)
+	if !ok {
+		return nil
+	}
+	principal, _ := value.(*Principal)
+	return principal
+}
+
+// 路径是否匹配其中一个模式
+// 模式以 /** 结尾时按照前缀匹配，否则使用 path.Match 匹配
+func matchPathPatterns(patterns []string, urlPath string) bool {
+	for _, item := range patterns {
+		if prefix, ok := strings.CutSuffix(item, "/**"); ok {
+			if urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(item, urlPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 路径是否需要认证，includes 为空时全部路径需要认证，excludes 优先于 includes
+func matchIncludeExclude(includes []string, excludes []string, urlPath string) bool {
+	if matchPathPatterns(excludes, urlPath) {
+		return false
+	}
+	return len(includes) == 0 || matchPathPatterns(includes, urlPath)
+}
+
+// 是否为JWT格式的令牌，使用 . 分隔的三部分
+func isJwtToken(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// 令牌名称
+func authTokenName(auth Auth) string {
+	if auth.TokenName == "" {
+		return DefaultAuthTokenName
+	}
+	return auth.TokenName
+}
+
+// 令牌有效时间
+func authExpire(auth Auth) time.Duration {
+	if auth.Expire <= 0 {
+		return time.Duration(DefaultAuthExpire) * time.Second
+	}
+	return time.Duration(auth.Expire) * time.Second
+}
+
+// 从请求中查找令牌，去除 Bearer 前缀
+func findAuthToken(c *gin.Context, tokenName string) string {
+	token := strings.TrimSpace(Tokens{}.FindToken(c, tokenName))
+	if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	return token
+}
+
+// 根据配置创建令牌存储
+// 依赖的redis或者数据源未开启时记录为启动错误，返回nil
+func (boot *GobootApplication) newTokenStore(auth Auth) TokenStore {
+	switch auth.Store {
+	case "redis":
+		if boot.Redis == nil {
+			boot.startupErrors = append(boot.startupErrors, "goboot.server.auth.store: redis store require enable redis config [goboot.server.redis.enable]")
+			return nil
+		}
+		return NewRedisTokenStore(boot.Redis)
+	case "db":
+		if boot.Db == nil {
+			boot.startupErrors = append(boot.startupErrors, "goboot.server.auth.store: db store require enable datasource config [goboot.server.datasource.enable]")
+			return nil
+		}
+		table := auth.Table
+		if table == "" {
+			table = DefaultAuthTable
+		}
+		return NewDbTokenStore(boot.Db, boot.Config.Goboot.Server.Datasource.Driver, table)
+	default:
+		return NewMemoryTokenStore()
+	}
+}
+
+// 认证中间件
+func (boot *GobootApplication) authMiddleware(auth Auth) gin.HandlerFunc {
+	if !auth.Enable {
+		return NextHandler
+	}
+	store := auth.Store
+	if store == "" {
+		store = "memory"
+	}
+	LogInfo("goboot enable auth, store: %v, token name: %v", store, authTokenName(auth))
+	expire := authExpire(auth)
+	jwtEnable := boot.Config.Goboot.Server.Jwt.Enable
+	return func(c *gin.Context) {
+		required := boot.matchRequestIncludeExclude(auth.Includes, auth.Excludes, c)
+		token := findAuthToken(c, authTokenName(auth))
+		// 同时开启JWT时，没有令牌和JWT格式的令牌交给JWT中间件校验
+		if jwtEnable && (token == "" || isJwtToken(token)) {
+			if required {
+				c.Set(contextAuthRequiredKey, true)
+			}
+			c.Next()
+			return
+		}
+		if token == "" {
+			if required {
+				boot.HandleError(c, NewStatusError(401, "unauthorized"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		principal, err := boot.TokenStore.Find(token)
+		if err != nil {
+			boot.HandleError(c, WrapStatusError(500, err))
+			c.Abort()
+			return
+		}
+		if principal == nil {
+			if required {
+				boot.HandleError(c, NewStatusError(401, "invalid or expired token"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		principal.Token = token
+
+		// 滑动续期，剩余时间不足一半时续期
+		if auth.Sliding && time.Until(principal.ExpireAt) < expire/2 {
+			principal.ExpireAt = time.Now().Add(expire)
+			if err := boot.TokenStore.Save(token, principal, expire); err != nil {
+				LogWarn("goboot auth renew token failure: %v, request id: %v", err, GetRequestId(c))
+			}
+		}
+
+		boot.setPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// 保存当前用户到上下文，访问日志中使用用户名或者用户ID
+func (boot *GobootApplication) setPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(ContextPrincipalKey, principal)
+	user := principal.Name
+	if user == "" {
+		user = principal.Id
+	}
+	c.Set(ContextUserKey, user)
+}
+
+// 登录，签发令牌并保存当前用户，返回令牌
+func (boot *GobootApplication) Login(c *gin.Context, principal *Principal) (string, error) {
+	auth := boot.Config.Goboot.Server.Auth
+	if !auth.Enable || boot.TokenStore == nil {
+		return "", errors.New("auth not enabled, set goboot.server.auth.enable")
+	}
+	if principal == nil {
+		return "", errors.New("auth login require principal")
+	}
+	expire := authExpire(auth)
+	token := Tokens{}.MakeToken()
+	principal.Token = token
+	principal.ExpireAt = time.Now().Add(expire)
+	if err := boot.TokenStore.Save(token, principal, expire); err != nil {
+		return "", err
+	}
+	boot.setPrincipal(c, principal)
+	return token, nil
+}
+
+// 退出登录，删除请求中的令牌
+func (boot *GobootApplication) Logout(c *gin.Context) error {
+	auth := boot.Config.Goboot.Server.Auth
+	if !auth.Enable || boot.TokenStore == nil {
+		return errors.New("auth not enabled, set goboot.server.auth.enable")
+	}
+	token := findAuthToken(c, authTokenName(auth))
+	if token == "" {
+		return nil
+	}
+	c.Set(ContextPrincipalKey, nil)
+	return boot.TokenStore.Remove(token)
+}
+
+// /////////////////////////////////////////////////////////
+// 内存令牌存储，只适用于单个节点
+// /////////////////////////////////////////////////////////
+
+// 每保存多少次清理一次过期令牌
+const memoryTokenSweepCount int = 256
+
+type MemoryTokenStore struct {
+	lock   sync.Mutex
+	tokens map[string]Principal
+	saves  int
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens: map[string]Principal{},
+	}
+}
+
+func (store *MemoryTokenStore) String() string {
+	return "memory"
+}
+
+func (store *MemoryTokenStore) Save(token string, principal *Principal, expire time.Duration) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	value := *principal
+	value.ExpireAt = time.Now().Add(expire)
+	store.tokens[token] = value
+	store.saves++
+	if store.saves%memoryTokenSweepCount == 0 {
+		now := time.Now()
+		for key, item := range store.tokens {
+			if now.After(item.ExpireAt) {
+				delete(store.tokens, key)
+			}
+		}
+	}
+	return nil
+}
+
+func (store *MemoryTokenStore) Find(token string) (*Principal, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	value, ok := store.tokens[token]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(value.ExpireAt) {
+		delete(store.tokens, token)
+		return nil, nil
+	}
+	return &value, nil
+}
+
+func (store *MemoryTokenStore) Remove(token string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	delete(store.tokens, token)
+	return nil
+}
+
+// /////////////////////////////////////////////////////////
+// redis令牌存储，当前用户序列化为JSON，使用redis的过期时间
+// /////////////////////////////////////////////////////////
+
+type RedisTokenStore struct {
+	Redis *RedisCli
+}
+
+func NewRedisTokenStore(redis *RedisCli) *RedisTokenStore {
+	return &RedisTokenStore{
+		Redis: redis,
+	}
+}
+
+func (store *RedisTokenStore) String() string {
+	return "redis"
+}
+
+func (store *RedisTokenStore) Save(token string, principal *Principal, expire time.Duration) error {
+	value := *principal
+	value.ExpireAt = time.Now().Add(expire)
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return store.Redis.Redis.Set(store.Redis.Context, authRedisKeyPrefix+token, string(bytes), expire).Err()
+}
+
+func (store *RedisTokenStore) Find(token string) (*Principal, error) {
+	str, err := store.Redis.Redis.Get(store.Redis.Context, authRedisKeyPrefix+token).Result()
+	if err == goredis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	principal := &Principal{}
+	err = json.Unmarshal([]byte(str), principal)
+	if err != nil {
+		return nil, err
+	}
+	return principal, nil
+}
+
+func (store *RedisTokenStore) Remove(token string) error {
+	return store.Redis.Redis.Del(store.Redis.Context, authRedisKeyPrefix+token).Err()
+}
+
+// /////////////////////////////////////////////////////////
+// 数据库令牌存储，首次使用时自动创建表
+// 表结构：token 令牌，principal 当前用户的JSON，expire_at 过期的unix时间戳，单位秒
+// /////////////////////////////////////////////////////////
+
+type DbTokenStore struct {
+	Db     *sql.DB
+	Driver string
+	Table  string
+
+	lock    sync.Mutex
+	created bool
+}
+
+func NewDbTokenStore(db *sql.DB, driver string, table string) *DbTokenStore {
+	return &DbTokenStore{
+		Db:     db,
+		Driver: driver,
+		Table:  table,
+	}
+}
+
+func (store *DbTokenStore) String() string {
+	return "db"
+}
+
+// 按照驱动转换占位符，postgres 使用 $n
+func (store *DbTokenStore) sql(query string) string {
+	if store.Driver != "postgres" {
+		return query
+	}
+	builder := strings.Builder{}
+	idx := 0
+	for _, ch := range query {
+		if ch == '?' {
+			idx++
+			builder.WriteString(fmt.Sprintf("$%v", idx))
+			continue
+		}
+		builder.WriteRune(ch)
+	}
+	return builder.String()
+}
+
+// 创建令牌表，失败时下次使用重试
+func (store *DbTokenStore) ensureTable() error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if store.created {
+		return nil
+	}
+	_, err := store.Db.Exec(fmt.Sprintf("create table if not exists %v (token varchar(64) not null primary key, principal text not null, expire_at bigint not null)", store.Table))
+	if err != nil {
+		return err
+	}
+	store.created = true
+	return nil
+}
+
+func (store *DbTokenStore) Save(token string, principal *Principal, expire time.Duration) error {
+	if err := store.ensureTable(); err != nil {
+		return err
+	}
+	value := *principal
+	value.ExpireAt = time.Now().Add(expire)
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	tx, err := store.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(store.sql(fmt.Sprintf("delete from %v where token = ?", store.Table)), token)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(store.sql(fmt.Sprintf("insert into %v (token, principal, expire_at) values (?, ?, ?)", store.Table)), token, string(bytes), value.ExpireAt.Unix())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (store *DbTokenStore) Find(token string) (*Principal, error) {
+	if err := store.ensureTable(); err != nil {
+		return nil, err
+	}
+	var str string
+	var expireAt int64
+	err := store.Db.QueryRow(store.sql(fmt.Sprintf("select principal, expire_at from %v where token = ?", store.Table)), token).Scan(&str, &expireAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() > expireAt {
+		store.Remove(token)
+		return nil, nil
+	}
+	principal := &Principal{}
+	err = json.Unmarshal([]byte(str), principal)
+	if err != nil {
+		return nil, err
+	}
+	return principal, nil
+}
+
+func (store *DbTokenStore) Remove(token string) error {
+	if err := store.ensureTable(); err != nil {
+		return err
+	}
+	_, err := store.Db.Exec(store.sql(fmt.Sprintf("delete from %v where token = ?", store.Table)), token)
+	return err
+}
@@ -0,0 +1,132 @@
+package goboot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type authTestApi struct{}
+
+func (api *authTestApi) Admin_DeleteUser() string { return "deleted" }
+
+func (api *authTestApi) Public_Info() string { return "info" }
+
+func (api *authTestApi) XP_Login(boot *GobootApplication, c *gin.Context) (string, error) {
+	return boot.Login(c, &Principal{Id: "1", Name: "tom"})
+}
+
+func (api *authTestApi) Me(principal *Principal) string {
+	return principal.Name
+}
+
+func newAuthTestApp(t *testing.T, includes []string) *GobootApplication {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.Mapping.Enable = true
+	config.Goboot.Server.Mapping.Items = []string{"/api/"}
+	config.Goboot.Server.Auth = Auth{
+		Enable:   true,
+		Includes: includes,
+		Excludes: []string{"/api/login"},
+	}
+	boot := GetConfigApplication(config, nil)
+	boot.AddHandlers(&authTestApi{})
+	boot.compileMappings()
+	return boot
+}
+
+func serveAuthTest(boot *GobootApplication, method string, target string, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	boot.App.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthAlternatePathSpelling(t *testing.T) {
+	boot := newAuthTestApp(t, []string{"/api/admin/**"})
+	for _, target := range []string{"/api/admin/delete-user", "/api/Admin/DeleteUser", "/api/Admin_DeleteUser", "/api/admin/DeleteUser"} {
+		if rec := serveAuthTest(boot, "GET", target, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%v status = %v, body %v", target, rec.Code, rec.Body.String())
+		}
+	}
+	for _, target := range []string{"/api/public/info", "/api/Public_Info"} {
+		if rec := serveAuthTest(boot, "GET", target, ""); rec.Code != 200 {
+			t.Errorf("%v status = %v, body %v", target, rec.Code, rec.Body.String())
+		}
+	}
+
+	token := serveAuthTest(boot, "POST", "/api/login", "").Body.String()
+	if rec := serveAuthTest(boot, "GET", "/api/Admin_DeleteUser", token); rec.Code != 200 || rec.Body.String() != "deleted" {
+		t.Fatalf("status with token = %v, body %v", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthLoginLogout(t *testing.T) {
+	boot := newAuthTestApp(t, nil)
+	if rec := serveAuthTest(boot, "GET", "/api/me", ""); rec.Code != 401 {
+		t.Fatalf("status without token = %v", rec.Code)
+	}
+	if rec := serveAuthTest(boot, "GET", "/api/me", "bad"); rec.Code != 401 {
+		t.Fatalf("status with invalid token = %v", rec.Code)
+	}
+	token := serveAuthTest(boot, "POST", "/api/login", "").Body.String()
+	if rec := serveAuthTest(boot, "GET", "/api/me", token); rec.Code != 200 || rec.Body.String() != "tom" {
+		t.Fatalf("status with token = %v, body %v", rec.Code, rec.Body.String())
+	}
+	if err := boot.TokenStore.Remove(token); err != nil {
+		t.Fatal(err)
+	}
+	if rec := serveAuthTest(boot, "GET", "/api/me", token); rec.Code != 401 {
+		t.Fatalf("status after remove = %v", rec.Code)
+	}
+}
+
+func TestMatchIncludeExclude(t *testing.T) {
+	includes := []string{"/api/**", "/admin/*/edit"}
+	excludes := []string{"/api/public/**"}
+	cases := map[string]bool{
+		"/api":              true,
+		"/api/user":         true,
+		"/apix":             false,
+		"/api/public":       false,
+		"/api/public/info":  false,
+		"/admin/user/edit":  true,
+		"/admin/user/view":  false,
+		"/static/index.css": false,
+	}
+	for urlPath, want := range cases {
+		if got := matchIncludeExclude(includes, excludes, urlPath); got != want {
+			t.Errorf("%v = %v, want %v", urlPath, got, want)
+		}
+	}
+	if !matchIncludeExclude(nil, excludes, "/any") {
+		t.Errorf("empty includes should match all paths")
+	}
+}
+
+func TestAuthStoreWithoutBackend(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	boot := GetConfigApplication(config, nil)
+	for _, store := range []string{"redis", "db"} {
+		if got := boot.newTokenStore(Auth{Enable: true, Store: store}); got != nil {
+			t.Errorf("%v store = %v, want nil without backend", store, got)
+		}
+	}
+	want := []string{
+		"goboot.server.auth.store: redis store require enable redis config [goboot.server.redis.enable]",
+		"goboot.server.auth.store: db store require enable datasource config [goboot.server.datasource.enable]",
+	}
+	if !reflect.DeepEqual(boot.startupErrors, want) {
+		t.Errorf("startup errors = %v", boot.startupErrors)
+	}
+}
@@ -0,0 +1,135 @@
+package goboot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 命令区
+// 在 main 函数开始处调用 RunGobootCommand，提供辅助命令
+//
+// goboot encrypt
+// 从标准输入读取明文，生成配置文件中使用的 ENC(...) 加密值
+// 口令只从环境变量或者口令文件获取，明文和口令都不出现在命令行参数中
+//
+// goboot config check [--config=./goboot.yml]
+// 校验配置文件，存在错误时退出码为1，可以用于CI
+// /////////////////////////////////////////////////////////
+
+// 命令处理函数，返回进程退出码
+type GobootCommand func(args []string) int
+
+// 已注册的命令，键为命令名称
+var gobootCommands = map[string]GobootCommand{
+	"encrypt": encryptCommand,
+	"config":  configCommand,
+}
+
+// 注册自定义命令
+func RegisterGobootCommand(name string, command GobootCommand) {
+	gobootCommands[name] = command
+}
+
+// 执行辅助命令
+// 第一个参数是已注册的命令时，执行命令并以命令的退出码结束进程
+// 否则直接返回，继续启动应用
+func RunGobootCommand(args []string) {
+	if len(args) == 0 {
+		return
+	}
+	command, ok := gobootCommands[args[0]]
+	if !ok {
+		return
+	}
+	os.Exit(command(args[1:]))
+}
+
+// 从命令参数中获取 --name=value 形式的选项，返回选项值和剩余参数
+func findCommandOption(args []string, name string) (string, []string) {
+	value := ""
+	rest := []string{}
+	prefix := "--" + name + "="
+	for _, item := range args {
+		if strings.HasPrefix(item, prefix) {
+			value = item[len(prefix):]
+			continue
+		}
+		rest = append(rest, item)
+	}
+	return value, rest
+}
+
+// 命令读取输入使用的标准输入
+var commandStdin io.Reader = os.Stdin
+
+// 从标准输入读取一行，去除行尾的换行，标准输入为终端时先打印提示
+func readCommandLine(prompt string) (string, error) {
+	if file, ok := commandStdin.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprint(os.Stderr, prompt)
+		}
+	}
+	line, err := bufio.NewReader(commandStdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// 加密命令
+func encryptCommand(args []string) int {
+	if len(args) != 0 {
+		fmt.Println("usage: goboot encrypt, read plain text from stdin, e.g. goboot encrypt < secret.txt")
+		fmt.Printf("key from env %v or key file %v\n", ConfigEncryptKeyEnv, DefaultConfigEncryptKeyFile)
+		return 2
+	}
+	key, err := GetConfigEncryptKey()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	plainText, err := readCommandLine("plain text: ")
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if plainText == "" {
+		fmt.Println("plain text is empty")
+		return 1
+	}
+	ret, err := EncryptConfigValue(plainText, key)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	fmt.Println(ret)
+	return 0
+}
+
+// 配置命令
+func configCommand(args []string) int {
+	cfgFile, args := findCommandOption(args, "config")
+	if len(args) != 1 || args[0] != "check" {
+		fmt.Println("usage: goboot config check [--config=<file>]")
+		return 2
+	}
+	if cfgFile == "" {
+		cfgFile = DefaultConfigFile
+	}
+	if _, err := os.Stat(cfgFile); err != nil {
+		LogError("goboot config check failure, config file %v not accessible, %v", cfgFile, err)
+		return 1
+	}
+	config := ResolveGobootConfig(cfgFile)
+	errs := ValidateGobootConfig(config)
+	if len(errs) > 0 {
+		LogConfigReport(errs)
+		return 1
+	}
+	LogInfo("goboot config check ok, files: %v", strings.Join(config.ConfigFiles, ", "))
+	return 0
+}
@@ -0,0 +1,36 @@
+package goboot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncryptCommand(t *testing.T) {
+	origin := commandStdin
+	t.Cleanup(func() { commandStdin = origin })
+	t.Setenv(ConfigEncryptKeyEnv, "secret")
+
+	commandStdin = strings.NewReader("0123\n")
+	if code := encryptCommand(nil); code != 0 {
+		t.Fatalf("exit code = %v", code)
+	}
+	// 明文和口令不再从命令行参数获取
+	if code := encryptCommand([]string{"0123", "--key=secret"}); code != 2 {
+		t.Fatalf("exit code with args = %v, want 2", code)
+	}
+	commandStdin = strings.NewReader("")
+	if code := encryptCommand(nil); code != 1 {
+		t.Fatalf("exit code with empty input = %v, want 1", code)
+	}
+}
+
+func TestReadCommandLine(t *testing.T) {
+	origin := commandStdin
+	t.Cleanup(func() { commandStdin = origin })
+	for input, want := range map[string]string{"a b\r\nnext": "a b", "last": "last", "": ""} {
+		commandStdin = strings.NewReader(input)
+		if got, err := readCommandLine(""); err != nil || got != want {
+			t.Errorf("read %q = %q, %v", input, got, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package goboot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 自定义配置绑定区
+// 将配置文件中的任意节点绑定到用户结构体
+// 绑定的配置同样经过环境配置合并，占位符解析，环境变量和命令行参数覆盖
+// 绑定后使用结构体的 binding/validate 标签进行校验
+//
+// type PaymentCfg struct {
+// 	ApiKey  string `yaml:"apiKey" validate:"required"`
+// 	Timeout int    `yaml:"timeout" binding:"min=1"`
+// }
+// cfg := &PaymentCfg{}
+// boot.BindConfig("myapp.payment", cfg)
+//
+// 绑定失败时，应用在 Run 时打印错误并退出
+// 绑定的结构体可以直接在自动映射的函数中注入
+// func (api *Api) Pay(ctx *goboot.CtxResp, cfg *PaymentCfg) {}
+// /////////////////////////////////////////////////////////
+
+var (
+	configValidatorOnce sync.Once
+	configValidators    []*validator.Validate
+)
+
+// 获取配置校验器，分别处理 binding 和 validate 标签
+// 校验错误的字段名使用yaml名称
+func getConfigValidators() []*validator.Validate {
+	configValidatorOnce.Do(func() {
+		for _, tag := range []string{"binding", "validate"} {
+			validate := validator.New()
+			validate.SetTagName(tag)
+			validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+				name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+				if name == "-" {
+					return ""
+				}
+				if name == "" {
+					name = strings.ToLower(field.Name)
+				}
+				return name
+			})
+			configValidators = append(configValidators, validate)
+		}
+	})
+	return configValidators
+}
+
+// 校验配置结构体，返回每个字段的错误信息
+func ValidateConfigStruct(prefix string, target interface{}) []string {
+	ret := []string{}
+	for _, validate := range getConfigValidators() {
+		err := validate.Struct(target)
+		if err == nil {
+			continue
+		}
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			ret = append(ret, fmt.Sprintf("%v: %v", prefix, err))
+			continue
+		}
+		for _, item := range fieldErrs {
+			// 去掉命名空间中的结构体名称
+			namespace := item.Namespace()
+			if idx := strings.Index(namespace, "."); idx >= 0 {
+				namespace = namespace[idx+1:]
+			}
+			msg := fmt.Sprintf("%v: failed on '%v'", joinConfigKey(prefix, namespace), item.Tag())
+			if item.Param() != "" {
+				msg = fmt.Sprintf("%v=%v", msg, item.Param())
+			}
+			msg = fmt.Sprintf("%v, value is %v", msg, item.Value())
+			ret = append(ret, msg)
+		}
+	}
+	return ret
+}
+
+// 将配置中指定路径的节点绑定到结构体，并进行校验
+// target 必须是结构体指针，绑定前的字段值作为默认值
+func BindConfigTo(config *GobootConfig, prefix string, target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind config %v require struct pointer, but got %T", prefix, target)
+	}
+
+	node := GetConfigMapValue(config.Properties, prefix)
+	if node != nil {
+		err := decodeConfigNode(node, target)
+		if err != nil {
+			return fmt.Errorf("bind config %v error: %w", prefix, err)
+		}
+	} else {
+		LogWarn("bind config %v not found in config files, use default values", prefix)
+	}
+
+	ApplyConfigOverrides(target, prefix, os.Environ(), os.Args[1:])
+
+	errs := ValidateConfigStruct(prefix, target)
+	if len(errs) > 0 {
+		return fmt.Errorf("bind config %v validate failure:\n\t%v", prefix, strings.Join(errs, "\n\t"))
+	}
+	return nil
+}
+
+// 绑定自定义配置节点到结构体
+// 绑定的结构体会被注册，可以在自动映射函数中注入
+// 绑定失败时，会在 Run 时打印错误并退出
+func (boot *GobootApplication) BindConfig(prefix string, target interface{}) *GobootApplication {
+	err := BindConfigTo(boot.Config, prefix, target)
+	if err != nil {
+		LogError("%v", err)
+		boot.startupErrors = append(boot.startupErrors, err.Error())
+		return boot
+	}
+	if boot.ConfigBeans == nil {
+		boot.ConfigBeans = map[reflect.Type]interface{}{}
+	}
+	boot.ConfigBeans[reflect.TypeOf(target)] = target
+	if boot.configBindings == nil {
+		boot.configBindings = map[string]interface{}{}
+	}
+	boot.configBindings[prefix] = target
+	LogInfo("goboot bind config %v to %T", prefix, target)
+	return boot
+}
@@ -0,0 +1,71 @@
+package goboot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type bindTestPayment struct {
+	ApiKey  string   `yaml:"apiKey" binding:"required"`
+	Timeout int      `yaml:"timeout" binding:"min=1"`
+	Retry   int      `yaml:"retry"`
+	Hosts   []string `yaml:"hosts"`
+}
+
+func bindTestConfig(payment map[string]interface{}) *GobootConfig {
+	return &GobootConfig{
+		Properties: map[string]interface{}{
+			"myapp": map[string]interface{}{"payment": payment},
+		},
+	}
+}
+
+func TestBindConfigTo(t *testing.T) {
+	config := bindTestConfig(map[string]interface{}{
+		"apiKey":  "key",
+		"timeout": "30",
+		"hosts":   []interface{}{"a", "b"},
+	})
+	target := &bindTestPayment{Retry: 3}
+
+	if err := BindConfigTo(config, "myapp.payment", target); err != nil {
+		t.Fatalf("bind error: %v", err)
+	}
+	want := &bindTestPayment{ApiKey: "key", Timeout: 30, Retry: 3, Hosts: []string{"a", "b"}}
+	if !reflect.DeepEqual(target, want) {
+		t.Fatalf("target = %+v, want %+v", target, want)
+	}
+}
+
+func TestBindConfigToValidate(t *testing.T) {
+	config := bindTestConfig(map[string]interface{}{"timeout": 0})
+	err := BindConfigTo(config, "myapp.payment", &bindTestPayment{})
+	if err == nil {
+		t.Fatalf("expected validate error")
+	}
+	for _, item := range []string{"myapp.payment.apiKey", "myapp.payment.timeout"} {
+		if !strings.Contains(err.Error(), item) {
+			t.Fatalf("error %v not contains %v", err, item)
+		}
+	}
+
+	if err := BindConfigTo(config, "myapp.payment", bindTestPayment{}); err == nil {
+		t.Fatalf("expected error for non pointer target")
+	}
+}
+
+func TestBindConfigRecordsStartupError(t *testing.T) {
+	boot := &GobootApplication{Config: bindTestConfig(map[string]interface{}{"timeout": 0})}
+	boot.BindConfig("myapp.payment", &bindTestPayment{})
+	if len(boot.startupErrors) != 1 {
+		t.Fatalf("startup errors = %v", boot.startupErrors)
+	}
+
+	boot = &GobootApplication{Config: bindTestConfig(map[string]interface{}{"apiKey": "key", "timeout": 1})}
+	target := &bindTestPayment{}
+	boot.BindConfig("myapp.payment", target)
+	if len(boot.startupErrors) != 0 || boot.ConfigBeans[reflect.TypeOf(target)] != target {
+		t.Fatalf("bind config not registered, errors: %v", boot.startupErrors)
+	}
+}
@@ -0,0 +1,189 @@
+package goboot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 配置合并区
+// 先加载 goboot.yml，再按顺序深度合并 profiles.active 中的每个环境配置
+// profiles.active 可以是逗号分隔的多个环境，例如：dev,local
+// 合并规则：
+// 1. 基础值直接覆盖
+// 2. 对象按键合并
+// 3. 列表按照 profiles.listMerge 配置处理，replace 替换(默认)，append 追加
+// /////////////////////////////////////////////////////////
+
+// 列表合并方式
+const (
+	ConfigListMergeReplace string = "replace"
+	ConfigListMergeAppend  string = "append"
+)
+
+// 从指定文件读取配置为map结构
+// 第二个返回值表示是否正确读取了配置
+func ReadGobootConfigMap(cfgFile string) (map[string]interface{}, bool) {
+	bytes, err := ioutil.ReadFile(cfgFile)
+	if err != nil {
+		LogWarn("read config file %v error of %v", cfgFile, err)
+		return nil, false
+	}
+	ret := map[string]interface{}{}
+	err = yaml.Unmarshal(bytes, &ret)
+	if err != nil {
+		LogWarn("parse yaml config file %v error of %v", cfgFile, err)
+		return nil, false
+	}
+	return ret, true
+}
+
+// 获取激活的环境列表
+func ParseActiveProfiles(active string) []string {
+	ret := []string{}
+	for _, item := range strings.Split(active, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+// 获取环境对应的配置文件，与主配置文件在同一目录
+// ./goboot.yml + dev --> ./goboot-dev.yml
+func ProfileConfigFile(cfgFile string, profile string) string {
+	return filepath.Join(filepath.Dir(cfgFile), fmt.Sprintf("goboot-%v.yml", profile))
+}
+
+// 从map中按照点号路径取值，不存在时返回nil
+func GetConfigMapValue(cfgMap map[string]interface{}, key string) interface{} {
+	var cur interface{} = cfgMap
+	for _, item := range strings.Split(key, ".") {
+		node, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = node[item]
+	}
+	return cur
+}
+
+// 深度合并配置，将src合并到dst中
+// sources 记录每个叶子配置项来自的文件
+func MergeConfigMap(dst map[string]interface{}, src map[string]interface{}, prefix string, source string, listMerge string, sources map[string]string) {
+	for key, val := range src {
+		keyPath := joinConfigKey(prefix, key)
+		srcMap, srcIsMap := val.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			MergeConfigMap(dstMap, srcMap, keyPath, source, listMerge, sources)
+			continue
+		}
+		srcList, srcIsList := val.([]interface{})
+		dstList, dstIsList := dst[key].([]interface{})
+		if srcIsList && dstIsList && listMerge == ConfigListMergeAppend {
+			merged := append(append([]interface{}{}, dstList...), srcList...)
+			dst[key] = merged
+			for i, item := range srcList {
+				recordConfigSources(joinConfigKey(keyPath, fmt.Sprint(len(dstList)+i)), item, source, sources)
+			}
+			continue
+		}
+		// 被替换的节点，移除原来的来源记录
+		for item := range sources {
+			if item == keyPath || strings.HasPrefix(item, keyPath+".") {
+				delete(sources, item)
+			}
+		}
+		dst[key] = val
+		recordConfigSources(keyPath, val, source, sources)
+	}
+}
+
+// 拼接点号路径
+func joinConfigKey(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// 记录配置项来源
+func recordConfigSources(keyPath string, val interface{}, source string, sources map[string]string) {
+	switch node := val.(type) {
+	case map[string]interface{}:
+		for key, item := range node {
+			recordConfigSources(joinConfigKey(keyPath, key), item, source, sources)
+		}
+	case []interface{}:
+		for i, item := range node {
+			recordConfigSources(joinConfigKey(keyPath, fmt.Sprint(i)), item, source, sources)
+		}
+	default:
+		sources[keyPath] = source
+	}
+}
+
+// 加载并合并主配置与所有激活的环境配置
+// 返回合并后的map，每个配置项的来源，以及实际加载的配置文件列表
+func LoadGobootConfigMap(cfgFile string) (cfgMap map[string]interface{}, sources map[string]string, files []string, ok bool) {
+	sources = map[string]string{}
+	cfgMap, ok = ReadGobootConfigMap(cfgFile)
+	if !ok {
+		return
+	}
+	LogInfo("load config yaml file %v", cfgFile)
+	files = append(files, cfgFile)
+	recordConfigSources("", cfgMap, cfgFile, sources)
+
+	listMerge := ConfigListMergeReplace
+	if val, isStr := GetConfigMapValue(cfgMap, "goboot.profiles.listMerge").(string); isStr && val != "" {
+		listMerge = val
+	}
+
+	active, _ := GetConfigMapValue(cfgMap, "goboot.profiles.active").(string)
+	profiles := ParseActiveProfiles(active)
+	if len(profiles) > 0 {
+		LogInfo("find profile active %v", strings.Join(profiles, ","))
+	}
+	for _, profile := range profiles {
+		profileFile := ProfileConfigFile(cfgFile, profile)
+		profileMap, pok := ReadGobootConfigMap(profileFile)
+		if !pok {
+			LogInfo("skip profile %v, config yaml file %v not available", profile, profileFile)
+			continue
+		}
+		LogInfo("merge config yaml file %v", profileFile)
+		files = append(files, profileFile)
+		MergeConfigMap(cfgMap, profileMap, "", profileFile, listMerge, sources)
+	}
+	return
+}
+
+// 打印配置项的来源，每个来源文件一行汇总，每个配置项的来源为 debug 级别
+func LogConfigSources(sources map[string]string) {
+	keys := make([]string, 0, len(sources))
+	counts := map[string]int{}
+	files := []string{}
+	for key, file := range sources {
+		keys = append(keys, key)
+		if counts[file] == 0 {
+			files = append(files, file)
+		}
+		counts[file]++
+	}
+	sort.Strings(keys)
+	sort.Strings(files)
+	for _, file := range files {
+		LogInfo("config source %v, %v key(s)", file, counts[file])
+	}
+	for _, key := range keys {
+		LogDebug("config %v <-- %v", key, sources[key])
+	}
+}
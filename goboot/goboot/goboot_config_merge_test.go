@@ -0,0 +1,122 @@
+package goboot
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeConfigMap(t *testing.T) {
+	dst := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port":  8080,
+			"items": []interface{}{"/api/"},
+			"proxy": map[string]interface{}{"enable": false, "name": "a"},
+		},
+	}
+	src := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port":  9090,
+			"items": []interface{}{"/rest/"},
+			"proxy": map[string]interface{}{"enable": true},
+		},
+	}
+	sources := map[string]string{}
+	recordConfigSources("", dst, "main.yml", sources)
+
+	MergeConfigMap(dst, src, "", "dev.yml", ConfigListMergeReplace, sources)
+
+	if got := GetConfigMapValue(dst, "server.port"); got != 9090 {
+		t.Fatalf("port = %v, want 9090", got)
+	}
+	if got := GetConfigMapValue(dst, "server.proxy.name"); got != "a" {
+		t.Fatalf("proxy.name = %v, want kept value a", got)
+	}
+	if got := GetConfigMapValue(dst, "server.proxy.enable"); got != true {
+		t.Fatalf("proxy.enable = %v, want true", got)
+	}
+	if got := GetConfigMapValue(dst, "server.items"); !reflect.DeepEqual(got, []interface{}{"/rest/"}) {
+		t.Fatalf("items = %v, want replaced", got)
+	}
+	want := map[string]string{
+		"server.port":         "dev.yml",
+		"server.items.0":      "dev.yml",
+		"server.proxy.enable": "dev.yml",
+		"server.proxy.name":   "main.yml",
+	}
+	if !reflect.DeepEqual(sources, want) {
+		t.Fatalf("sources = %v, want %v", sources, want)
+	}
+}
+
+func TestMergeConfigMapAppend(t *testing.T) {
+	dst := map[string]interface{}{"items": []interface{}{"a"}}
+	src := map[string]interface{}{"items": []interface{}{"b", "c"}}
+	sources := map[string]string{"items.0": "main.yml"}
+
+	MergeConfigMap(dst, src, "", "dev.yml", ConfigListMergeAppend, sources)
+
+	if !reflect.DeepEqual(dst["items"], []interface{}{"a", "b", "c"}) {
+		t.Fatalf("items = %v", dst["items"])
+	}
+	if sources["items.0"] != "main.yml" || sources["items.2"] != "dev.yml" {
+		t.Fatalf("sources = %v", sources)
+	}
+}
+
+func TestLoadGobootConfigMapProfiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("goboot.yml", "goboot:\n  profiles:\n    active: dev, local, missing\n  server:\n    port: 8080\n    bannerPath: main\n")
+	write("goboot-dev.yml", "goboot:\n  server:\n    port: 8081\n    bannerPath: dev\n")
+	write("goboot-local.yml", "goboot:\n  server:\n    port: 8082\n")
+
+	cfgMap, sources, files, ok := LoadGobootConfigMap(filepath.Join(dir, "goboot.yml"))
+	if !ok {
+		t.Fatalf("load failure")
+	}
+	if len(files) != 3 {
+		t.Fatalf("files = %v, want main, dev and local", files)
+	}
+	if got := GetConfigMapValue(cfgMap, "goboot.server.port"); got != 8082 {
+		t.Fatalf("port = %v, want 8082", got)
+	}
+	if got := GetConfigMapValue(cfgMap, "goboot.server.bannerPath"); got != "dev" {
+		t.Fatalf("bannerPath = %v, want dev", got)
+	}
+	if sources["goboot.server.port"] != filepath.Join(dir, "goboot-local.yml") {
+		t.Fatalf("port source = %v", sources["goboot.server.port"])
+	}
+}
+
+func TestParseActiveProfiles(t *testing.T) {
+	got := ParseActiveProfiles(" dev, ,local ")
+	if !reflect.DeepEqual(got, []string{"dev", "local"}) {
+		t.Fatalf("profiles = %v", got)
+	}
+}
+
+func TestLogConfigSources(t *testing.T) {
+	resetTestLogging(t)
+	buf := &bytes.Buffer{}
+	SetLogHandler(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	LogConfigSources(map[string]string{
+		"goboot.server.port":      "goboot.yml",
+		"goboot.server.gzip":      "goboot.yml",
+		"goboot.logging.level":    "goboot-dev.yml",
+		"goboot.server.cors.list": "goboot-dev.yml",
+		"goboot.server.mapping":   "goboot.yml",
+	})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "goboot-dev.yml, 2 key(s)") || !strings.Contains(lines[1], "goboot.yml, 3 key(s)") {
+		t.Fatalf("output = %v", buf.String())
+	}
+}
@@ -0,0 +1,328 @@
+package goboot
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 配置覆盖区
+// 配置优先级从低到高：
+// goboot.yml < goboot-${profile}.yml < 环境变量 < 命令行参数
+//
+// 环境变量名由yaml路径转换而来，点号替换为下划线并大写
+// 驼峰的键既可以直接大写，也可以按驼峰拆分为下划线
+// goboot.server.port --> GOBOOT_SERVER_PORT
+// goboot.server.bannerPath --> GOBOOT_SERVER_BANNERPATH 或 GOBOOT_SERVER_BANNER_PATH
+// goboot.server.proxy.items[0].name --> GOBOOT_SERVER_PROXY_ITEMS_0_NAME
+//
+// 命令行参数使用 --yaml路径=值 的格式，不区分大小写
+// --goboot.server.port=9090
+// --goboot.server.proxy.items[0].name=baidu 或 --goboot.server.proxy.items.0.name=baidu
+//
+// 字符串列表使用逗号分隔
+// GOBOOT_SERVER_MAPPING_ITEMS=/api/,/rest/
+//
+// 使用 BindConfig 绑定的自定义配置同样适用
+// myapp.payment.apiKey --> MYAPP_PAYMENT_APIKEY 或 MYAPP_PAYMENT_API_KEY
+//
+// 只考虑带有前缀的环境变量，GobootConfig 为 GOBOOT_
+// 绑定的配置为配置节点路径对应的环境变量名加下划线，例如 MYAPP_PAYMENT_
+// /////////////////////////////////////////////////////////
+
+// 环境变量前缀
+const ConfigEnvPrefix string = "GOBOOT_"
+
+// 命令行参数前缀
+const ConfigArgPrefix string = "--"
+
+// 一个配置覆盖值
+type configOverride struct {
+	Value  string
+	Source string
+}
+
+// 配置覆盖值集合
+type configOverrides struct {
+	// 环境变量，键为大写的环境变量名
+	envs map[string]configOverride
+	// 命令行参数，键为小写的点号路径，数组下标统一为 .i 形式
+	args map[string]configOverride
+}
+
+var configArgIndexRegex = regexp.MustCompile(`\[(\d+)\]`)
+
+// 解析环境变量和命令行参数，得到覆盖值集合
+// 只保留以 envPrefixes 中任意一个开头的环境变量
+func newConfigOverrides(environ []string, args []string, envPrefixes []string) *configOverrides {
+	ret := &configOverrides{
+		envs: map[string]configOverride{},
+		args: map[string]configOverride{},
+	}
+	for _, item := range environ {
+		idx := strings.Index(item, "=")
+		if idx <= 0 {
+			continue
+		}
+		key := item[:idx]
+		if !hasConfigEnvPrefix(strings.ToUpper(key), envPrefixes) {
+			continue
+		}
+		ret.envs[strings.ToUpper(key)] = configOverride{
+			Value:  item[idx+1:],
+			Source: "env " + key,
+		}
+	}
+	for _, item := range args {
+		if !strings.HasPrefix(item, ConfigArgPrefix) {
+			continue
+		}
+		item = item[len(ConfigArgPrefix):]
+		idx := strings.Index(item, "=")
+		if idx <= 0 {
+			continue
+		}
+		key := normalizeConfigArgKey(item[:idx])
+		ret.args[key] = configOverride{
+			Value:  item[idx+1:],
+			Source: "arg --" + item[:idx],
+		}
+	}
+	return ret
+}
+
+// 环境变量名是否以其中一个前缀开头
+func hasConfigEnvPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// 将命令行参数键转换为统一形式
+// goboot.server.proxy.items[0].name --> goboot.server.proxy.items.0.name
+func normalizeConfigArgKey(key string) string {
+	key = configArgIndexRegex.ReplaceAllString(key, ".$1")
+	return strings.ToLower(strings.Trim(key, "."))
+}
+
+// 将驼峰拆分为下划线形式
+// bannerPath --> banner_Path
+func splitCamelCase(str string) string {
+	var builder strings.Builder
+	runes := []rune(str)
+	for i, ch := range runes {
+		if i > 0 && unicode.IsUpper(ch) && !unicode.IsUpper(runes[i-1]) {
+			builder.WriteRune('_')
+		}
+		builder.WriteRune(ch)
+	}
+	return builder.String()
+}
+
+// 根据yaml路径得到可能的环境变量名
+func configEnvNames(paths []string) []string {
+	plain := strings.ToUpper(strings.Join(paths, "_"))
+	snakes := make([]string, 0, len(paths))
+	for _, item := range paths {
+		snakes = append(snakes, splitCamelCase(item))
+	}
+	snake := strings.ToUpper(strings.Join(snakes, "_"))
+	if snake == plain {
+		return []string{plain}
+	}
+	return []string{plain, snake}
+}
+
+// 查找指定路径的覆盖值，命令行参数优先于环境变量
+func (ov *configOverrides) lookup(paths []string) (configOverride, bool) {
+	if val, ok := ov.args[strings.ToLower(strings.Join(paths, "."))]; ok {
+		return val, true
+	}
+	for _, name := range configEnvNames(paths) {
+		if val, ok := ov.envs[name]; ok {
+			return val, true
+		}
+	}
+	return configOverride{}, false
+}
+
+// 查找指定路径下，覆盖值中出现的最大数组下标，没有时返回-1
+func (ov *configOverrides) maxIndex(paths []string) int {
+	ret := -1
+	findIndex := func(rest string, sep string) {
+		idx := strings.Index(rest, sep)
+		if idx > 0 {
+			rest = rest[:idx]
+		}
+		num, err := strconv.Atoi(rest)
+		if err == nil && num > ret {
+			ret = num
+		}
+	}
+	argPrefix := strings.ToLower(strings.Join(paths, ".")) + "."
+	for key := range ov.args {
+		if strings.HasPrefix(key, argPrefix) {
+			findIndex(key[len(argPrefix):], ".")
+		}
+	}
+	for _, name := range configEnvNames(paths) {
+		envPrefix := name + "_"
+		for key := range ov.envs {
+			if strings.HasPrefix(key, envPrefix) {
+				findIndex(key[len(envPrefix):], "_")
+			}
+		}
+	}
+	return ret
+}
+
+// 使用当前进程的环境变量和命令行参数覆盖配置
+func ApplyGobootConfigOverrides(config *GobootConfig) {
+	ApplyConfigOverrides(config, "", os.Environ(), os.Args[1:])
+}
+
+// 使用给定的环境变量和命令行参数覆盖结构体配置
+// target 必须是结构体指针，prefix 为结构体在yaml中的路径，根节点时为空
+// 返回被覆盖的yaml路径列表
+func ApplyConfigOverrides(target interface{}, prefix string, environ []string, args []string) []string {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("config override target require struct pointer, but got %v", rv.Type()))
+	}
+	paths := []string{}
+	envPrefixes := []string{ConfigEnvPrefix}
+	if prefix != "" {
+		paths = strings.Split(prefix, ".")
+		envPrefixes = []string{}
+		for _, name := range configEnvNames(paths) {
+			envPrefixes = append(envPrefixes, name+"_")
+		}
+	}
+	overrides := newConfigOverrides(environ, args, envPrefixes)
+	if len(overrides.envs) == 0 && len(overrides.args) == 0 {
+		return nil
+	}
+	applied := []string{}
+	overrides.apply(rv.Elem(), paths, &applied)
+	sort.Strings(applied)
+	return applied
+}
+
+// 递归处理结构体字段
+func (ov *configOverrides) apply(rv reflect.Value, paths []string, applied *[]string) {
+	switch rv.Kind() {
+	case reflect.Struct:
+		rtype := rv.Type()
+		for i := 0; i < rtype.NumField(); i++ {
+			field := rtype.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			ov.apply(rv.Field(i), append(paths[:len(paths):len(paths)], name), applied)
+		}
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			ov.apply(rv.Elem(), paths, applied)
+		}
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Struct {
+			maxIdx := ov.maxIndex(paths)
+			if maxIdx >= rv.Len() {
+				grown := reflect.MakeSlice(rv.Type(), maxIdx+1, maxIdx+1)
+				reflect.Copy(grown, rv)
+				rv.Set(grown)
+			}
+			for i := 0; i < rv.Len(); i++ {
+				ov.apply(rv.Index(i), append(paths[:len(paths):len(paths)], strconv.Itoa(i)), applied)
+			}
+			return
+		}
+		ov.applyValue(rv, paths, applied)
+	default:
+		ov.applyValue(rv, paths, applied)
+	}
+}
+
+// 设置叶子节点的值
+func (ov *configOverrides) applyValue(rv reflect.Value, paths []string, applied *[]string) {
+	val, ok := ov.lookup(paths)
+	if !ok {
+		return
+	}
+	key := strings.Join(paths, ".")
+	err := SetConfigValueFromString(rv, val.Value)
+	if err != nil {
+		LogWarn("override config %v from %v error of %v", key, val.Source, err)
+		return
+	}
+	LogInfo("override config %v from %v", key, val.Source)
+	*applied = append(*applied, key)
+}
+
+// 将字符串转换为对应类型并设置值
+// 支持字符串，数值，布尔，以及逗号分隔的基础类型列表
+func SetConfigValueFromString(rv reflect.Value, str string) error {
+	if !rv.CanSet() {
+		return fmt.Errorf("value can not set")
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(str)
+	case reflect.Bool:
+		val, err := strconv.ParseBool(strings.TrimSpace(str))
+		if err != nil {
+			return err
+		}
+		rv.SetBool(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val, err := strconv.ParseInt(strings.TrimSpace(str), 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val, err := strconv.ParseUint(strings.TrimSpace(str), 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(val)
+	case reflect.Float32, reflect.Float64:
+		val, err := strconv.ParseFloat(strings.TrimSpace(str), rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(val)
+	case reflect.Slice:
+		parts := []string{}
+		for _, item := range strings.Split(str, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				parts = append(parts, item)
+			}
+		}
+		list := reflect.MakeSlice(rv.Type(), len(parts), len(parts))
+		for i, item := range parts {
+			err := SetConfigValueFromString(list.Index(i), item)
+			if err != nil {
+				return err
+			}
+		}
+		rv.Set(list)
+	default:
+		return fmt.Errorf("un-support config value type: %v", rv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package goboot
+
+import (
+	"reflect"
+	"testing"
+)
+
+type overrideTestItem struct {
+	Name string `yaml:"name"`
+}
+
+type overrideTestConfig struct {
+	Home       string             `yaml:"home"`
+	Goboot     overrideTestGoboot `yaml:"goboot"`
+	Unexported string
+}
+
+type overrideTestGoboot struct {
+	Port       int                `yaml:"port"`
+	BannerPath string             `yaml:"bannerPath"`
+	Enable     bool               `yaml:"enable"`
+	Items      []string           `yaml:"items"`
+	Proxies    []overrideTestItem `yaml:"proxies"`
+}
+
+func TestApplyConfigOverrides(t *testing.T) {
+	config := &overrideTestConfig{}
+	environ := []string{
+		"HOME=/root",
+		"GOBOOT_PORT=8081",
+		"GOBOOT_BANNER_PATH=./banner.txt",
+		"GOBOOT_ENABLE=true",
+		"GOBOOT_ITEMS=/api/, /rest/",
+		"GOBOOT_PROXIES_1_NAME=second",
+	}
+	args := []string{"--goboot.port=9090", "--goboot.proxies[0].name=first", "ignored"}
+
+	applied := ApplyConfigOverrides(config, "", environ, args)
+
+	if config.Home != "" {
+		t.Fatalf("env without prefix applied: home = %v", config.Home)
+	}
+	if config.Goboot.Port != 9090 {
+		t.Fatalf("port = %v, want arg value 9090", config.Goboot.Port)
+	}
+	if config.Goboot.BannerPath != "./banner.txt" || !config.Goboot.Enable {
+		t.Fatalf("env values not applied: %+v", config.Goboot)
+	}
+	if !reflect.DeepEqual(config.Goboot.Items, []string{"/api/", "/rest/"}) {
+		t.Fatalf("items = %v", config.Goboot.Items)
+	}
+	if len(config.Goboot.Proxies) != 2 || config.Goboot.Proxies[0].Name != "first" || config.Goboot.Proxies[1].Name != "second" {
+		t.Fatalf("proxies = %+v", config.Goboot.Proxies)
+	}
+	want := []string{"goboot.bannerPath", "goboot.enable", "goboot.items", "goboot.port", "goboot.proxies.0.name", "goboot.proxies.1.name"}
+	if !reflect.DeepEqual(applied, want) {
+		t.Fatalf("applied = %v, want %v", applied, want)
+	}
+}
+
+func TestApplyConfigOverridesWithPrefix(t *testing.T) {
+	type payment struct {
+		Timeout int    `yaml:"timeout"`
+		ApiKey  string `yaml:"apiKey"`
+	}
+	config := &payment{}
+	environ := []string{"TIMEOUT=1", "GOBOOT_TIMEOUT=2", "MYAPP_PAYMENT_TIMEOUT=60", "MYAPP_PAYMENT_API_KEY=key"}
+
+	ApplyConfigOverrides(config, "myapp.payment", environ, nil)
+
+	if config.Timeout != 60 || config.ApiKey != "key" {
+		t.Fatalf("config = %+v", config)
+	}
+}
+
+func TestSetConfigValueFromStringError(t *testing.T) {
+	var port int
+	if err := SetConfigValueFromString(reflect.ValueOf(&port).Elem(), "abc"); err == nil {
+		t.Fatalf("expected error for invalid int")
+	}
+}
@@ -0,0 +1,289 @@
+package goboot
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 配置占位符与加密区
+// 配置中的字符串值支持 ${名称:默认值} 形式的占位符
+// 名称优先作为配置的点号路径查找，例如 ${goboot.application.name}
+// 找不到时作为环境变量名查找，例如 ${DB_PASSWORD}
+// 都找不到时使用默认值，没有默认值时保留原文并打印警告
+// 替换结果始终保持为字符串，绑定到结构时再按字段类型转换，因此 port: ${PORT:8080} 可以得到数值
+// 字符串字段不会被重新解析，例如密码 0123 和 0x1F 保持原样
+//
+// 配置中 ENC(...) 形式的值会被解密
+// 使用 AES-256-GCM 算法，秘钥使用 scrypt 从口令和随机盐派生，加密值为 base64(盐 + nonce + 密文)
+// 口令从环境变量 GOBOOT_ENCRYPT_KEY 获取
+// 或者从环境变量 GOBOOT_ENCRYPT_KEY_FILE 指定的文件获取，默认文件为 ./goboot.key
+// 加密值可以使用命令生成：goboot encrypt，明文从标准输入读取
+// /////////////////////////////////////////////////////////
+
+// 加密口令环境变量名
+const ConfigEncryptKeyEnv string = "GOBOOT_ENCRYPT_KEY"
+
+// 加密口令文件环境变量名
+const ConfigEncryptKeyFileEnv string = "GOBOOT_ENCRYPT_KEY_FILE"
+
+// 默认加密口令文件
+const DefaultConfigEncryptKeyFile string = "./goboot.key"
+
+// 占位符最大嵌套解析深度
+const maxPlaceholderDepth int = 16
+
+// 获取配置加密口令
+// 优先环境变量，其次口令文件
+func GetConfigEncryptKey() (string, error) {
+	if key := os.Getenv(ConfigEncryptKeyEnv); key != "" {
+		return key, nil
+	}
+	keyFile := os.Getenv(ConfigEncryptKeyFileEnv)
+	if keyFile == "" {
+		keyFile = DefaultConfigEncryptKeyFile
+	}
+	bytes, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return "", fmt.Errorf("not found encrypt key, require env %v or key file %v", ConfigEncryptKeyEnv, keyFile)
+	}
+	key := strings.TrimSpace(string(bytes))
+	if key == "" {
+		return "", fmt.Errorf("encrypt key file %v is empty", keyFile)
+	}
+	return key, nil
+}
+
+// scrypt 派生秘钥的参数
+const (
+	configCipherSaltSize int = 16
+	configCipherScryptN  int = 1 << 15
+	configCipherScryptR  int = 8
+	configCipherScryptP  int = 1
+)
+
+// 使用口令和盐派生秘钥，得到AES-GCM
+func newConfigCipher(key string, salt []byte) (cipher.AEAD, error) {
+	derived, err := scrypt.Key([]byte(key), salt, configCipherScryptN, configCipherScryptR, configCipherScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// 加密配置值，返回 ENC(...) 形式
+func EncryptConfigValue(plainText string, key string) (string, error) {
+	salt := make([]byte, configCipherSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	}
+	gcm, err := newConfigCipher(key, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(append(salt, nonce...), nonce, []byte(plainText), nil)
+	return "ENC(" + base64.StdEncoding.EncodeToString(sealed) + ")", nil
+}
+
+// 解密配置值，入参可以是 ENC(...) 形式，也可以是括号内的内容
+func DecryptConfigValue(cipherText string, key string) (string, error) {
+	if IsEncryptedConfigValue(cipherText) {
+		cipherText = strings.TrimSpace(cipherText)
+		cipherText = cipherText[len("ENC(") : len(cipherText)-1]
+	}
+	bytes, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return "", err
+	}
+	if len(bytes) < configCipherSaltSize {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+	gcm, err := newConfigCipher(key, bytes[:configCipherSaltSize])
+	if err != nil {
+		return "", err
+	}
+	bytes = bytes[configCipherSaltSize:]
+	if len(bytes) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+	plain, err := gcm.Open(nil, bytes[:gcm.NonceSize()], bytes[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt error, maybe wrong key: %w", err)
+	}
+	return string(plain), nil
+}
+
+// 是否是 ENC(...) 形式的加密值
+func IsEncryptedConfigValue(str string) bool {
+	str = strings.TrimSpace(str)
+	return strings.HasPrefix(str, "ENC(") && strings.HasSuffix(str, ")")
+}
+
+// 配置占位符解析器
+type configPlaceholderResolver struct {
+	root map[string]interface{}
+	key  string
+	// 加密口令是否已经获取过
+	keyLoaded bool
+	keyErr    error
+	// 解析失败的错误信息
+	errs []string
+}
+
+// 解析配置map中所有的占位符与加密值
+// 解析失败的值保留原文，并打印警告
+// 返回解析失败的错误信息
+func ResolveConfigPlaceholders(cfgMap map[string]interface{}) []string {
+	resolver := &configPlaceholderResolver{
+		root: cfgMap,
+	}
+	resolver.resolveNode("", cfgMap)
+	return resolver.errs
+}
+
+// 递归解析节点
+func (resolver *configPlaceholderResolver) resolveNode(keyPath string, node interface{}) interface{} {
+	switch val := node.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			val[key] = resolver.resolveNode(joinConfigKey(keyPath, key), item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = resolver.resolveNode(joinConfigKey(keyPath, fmt.Sprint(i)), item)
+		}
+		return val
+	case string:
+		ret, err := resolver.resolveValue(val, 0)
+		if err != nil {
+			LogWarn("resolve config %v error of %v", keyPath, err)
+			resolver.errs = append(resolver.errs, fmt.Sprintf("%v: %v", keyPath, err))
+			return val
+		}
+		return ret
+	}
+	return node
+}
+
+// 解析一个字符串值，返回解析后的值
+func (resolver *configPlaceholderResolver) resolveValue(str string, depth int) (string, error) {
+	if depth > maxPlaceholderDepth {
+		return "", fmt.Errorf("placeholder nested too deep, maybe circular reference: %v", str)
+	}
+	if strings.Contains(str, "${") {
+		ret, err := resolver.replacePlaceholders(str, depth)
+		if err != nil {
+			return "", err
+		}
+		str = ret
+	}
+	if IsEncryptedConfigValue(str) {
+		if !resolver.keyLoaded {
+			resolver.key, resolver.keyErr = GetConfigEncryptKey()
+			resolver.keyLoaded = true
+		}
+		if resolver.keyErr != nil {
+			return "", resolver.keyErr
+		}
+		return DecryptConfigValue(str, resolver.key)
+	}
+	return str, nil
+}
+
+// 查找占位符的结束位置，支持嵌套，找不到返回-1
+func findPlaceholderEnd(str string, start int) int {
+	level := 1
+	for i := start; i < len(str); i++ {
+		if strings.HasPrefix(str[i:], "${") {
+			level++
+			i++
+		} else if str[i] == '}' {
+			level--
+			if level == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// 替换字符串中所有的占位符
+func (resolver *configPlaceholderResolver) replacePlaceholders(str string, depth int) (string, error) {
+	var builder strings.Builder
+	for {
+		idx := strings.Index(str, "${")
+		if idx < 0 {
+			builder.WriteString(str)
+			break
+		}
+		end := findPlaceholderEnd(str, idx+2)
+		if end < 0 {
+			return "", fmt.Errorf("placeholder not closed: %v", str)
+		}
+		builder.WriteString(str[:idx])
+		val, err := resolver.lookupPlaceholder(str[idx+2:end], depth)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(val)
+		str = str[end+1:]
+	}
+	return builder.String(), nil
+}
+
+// 查找占位符的值
+func (resolver *configPlaceholderResolver) lookupPlaceholder(expr string, depth int) (string, error) {
+	// 名称中可能也有占位符
+	if strings.Contains(expr, "${") {
+		ret, err := resolver.replacePlaceholders(expr, depth+1)
+		if err != nil {
+			return "", err
+		}
+		expr = ret
+	}
+	name := expr
+	defaultValue := ""
+	hasDefault := false
+	if idx := strings.Index(expr, ":"); idx >= 0 {
+		name = expr[:idx]
+		defaultValue = expr[idx+1:]
+		hasDefault = true
+	}
+	name = strings.TrimSpace(name)
+
+	if val := GetConfigMapValue(resolver.root, name); val != nil {
+		switch node := val.(type) {
+		case map[string]interface{}, []interface{}:
+			return "", fmt.Errorf("placeholder ${%v} reference a non-scalar config", name)
+		case string:
+			return resolver.resolveValue(node, depth+1)
+		default:
+			return fmt.Sprint(node), nil
+		}
+	}
+	if val, ok := os.LookupEnv(name); ok {
+		return val, nil
+	}
+	if hasDefault {
+		return defaultValue, nil
+	}
+	return "", fmt.Errorf("un-resolved placeholder ${%v}", name)
+}
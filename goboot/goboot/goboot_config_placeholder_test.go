@@ -0,0 +1,133 @@
+package goboot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveConfigPlaceholders(t *testing.T) {
+	t.Setenv("GOBOOT_TEST_HOST", "db.local")
+	cfgMap := map[string]interface{}{
+		"app": map[string]interface{}{
+			"name": "demo",
+			"url":  "http://${GOBOOT_TEST_HOST}:${app.port:3306}/${app.name}",
+			"port": "${GOBOOT_TEST_MISSING:3307}",
+			"list": []interface{}{"${app.name}-1"},
+		},
+	}
+
+	errs := ResolveConfigPlaceholders(cfgMap)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if got := GetConfigMapValue(cfgMap, "app.url"); got != "http://db.local:3307/demo" {
+		t.Fatalf("url = %v", got)
+	}
+	if got := GetConfigMapValue(cfgMap, "app.port"); got != "3307" {
+		t.Fatalf("port = %#v, want string 3307", got)
+	}
+	if got := GetConfigMapValue(cfgMap, "app.list").([]interface{})[0]; got != "demo-1" {
+		t.Fatalf("list item = %v", got)
+	}
+}
+
+func TestResolveConfigPlaceholdersError(t *testing.T) {
+	cfgMap := map[string]interface{}{
+		"a":    "${b}",
+		"b":    "${a}",
+		"miss": "${GOBOOT_TEST_NOT_EXIST}",
+	}
+	errs := ResolveConfigPlaceholders(cfgMap)
+	if len(errs) != 3 {
+		t.Fatalf("errors = %v, want 3", errs)
+	}
+	if cfgMap["miss"] != "${GOBOOT_TEST_NOT_EXIST}" {
+		t.Fatalf("un-resolved value should keep origin text, got %v", cfgMap["miss"])
+	}
+}
+
+// 整个值是占位符时，字符串字段保持原文，数值和布尔字段按类型转换
+func TestResolveWholePlaceholderKeepString(t *testing.T) {
+	t.Setenv("GOBOOT_TEST_PASSWORD", "0123")
+	t.Setenv("GOBOOT_TEST_USERNAME", "0x1F")
+	t.Setenv("GOBOOT_TEST_PORT", "0123")
+	cfgMap := map[string]interface{}{
+		"goboot": map[string]interface{}{
+			"server": map[string]interface{}{
+				"port": "${GOBOOT_TEST_SERVER_PORT:9090}",
+				"datasource": map[string]interface{}{
+					"enable":   "${GOBOOT_TEST_ENABLE:true}",
+					"port":     "${GOBOOT_TEST_PORT}",
+					"username": "${GOBOOT_TEST_USERNAME}",
+					"password": "${GOBOOT_TEST_PASSWORD}",
+				},
+			},
+		},
+	}
+
+	errs := ResolveConfigPlaceholders(cfgMap)
+	config := &GobootConfig{}
+	errs = append(errs, decodeGobootConfigMap(cfgMap, nil, config)...)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+
+	ds := config.Goboot.Server.Datasource
+	if ds.Password != "0123" {
+		t.Fatalf("password = %v, want 0123", ds.Password)
+	}
+	if ds.Username != "0x1F" {
+		t.Fatalf("username = %v, want 0x1F", ds.Username)
+	}
+	if ds.Port != 123 || !ds.Enable {
+		t.Fatalf("datasource = %+v, want port 123 and enable", ds)
+	}
+	if config.Goboot.Server.Port != 9090 {
+		t.Fatalf("server port = %v, want 9090", config.Goboot.Server.Port)
+	}
+}
+
+func TestDecodeConfigTypeError(t *testing.T) {
+	cfgMap := map[string]interface{}{
+		"goboot": map[string]interface{}{
+			"server": map[string]interface{}{"port": "abc"},
+		},
+	}
+	errs := decodeGobootConfigMap(cfgMap, nil, &GobootConfig{})
+	if len(errs) != 1 || !strings.Contains(errs[0], "goboot.server.port") {
+		t.Fatalf("errors = %v", errs)
+	}
+}
+
+func TestEncryptConfigValue(t *testing.T) {
+	enc, err := EncryptConfigValue("0123", "secret")
+	if err != nil {
+		t.Fatalf("encrypt error: %v", err)
+	}
+	if !IsEncryptedConfigValue(enc) {
+		t.Fatalf("not ENC(...) form: %v", enc)
+	}
+	plain, err := DecryptConfigValue(enc, "secret")
+	if err != nil || plain != "0123" {
+		t.Fatalf("decrypt = %v, %v", plain, err)
+	}
+	if _, err := DecryptConfigValue(enc, "wrong"); err == nil {
+		t.Fatalf("expected error with wrong key")
+	}
+	// 每次加密使用随机的盐，相同明文的加密值不同
+	if other, _ := EncryptConfigValue("0123", "secret"); other == enc {
+		t.Fatalf("encrypted values should differ with random salt")
+	}
+	if _, err := DecryptConfigValue("ENC(AAAA)", "secret"); err == nil {
+		t.Fatalf("expected error for short value")
+	}
+
+	t.Setenv(ConfigEncryptKeyEnv, "secret")
+	cfgMap := map[string]interface{}{"password": "${GOBOOT_TEST_ENC:" + enc + "}"}
+	if errs := ResolveConfigPlaceholders(cfgMap); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if cfgMap["password"] != "0123" {
+		t.Fatalf("password = %v, want 0123", cfgMap["password"])
+	}
+}
@@ -0,0 +1,294 @@
+package goboot
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 配置热加载区
+// 开启 goboot.server.hotReload.enable 后，定时检查配置文件(主配置和激活的环境配置)的修改时间
+// 文件变化时重新解析配置，校验通过后替换运行时安全的配置
+// 运行时可替换：proxy, cors, gzip, fileServer, staticResources, mapping, 以及日志级别 logging.level/levels
+// 其他配置(例如 port, datasource)的变化需要重启才能生效，会打印警告并记录为待重启
+// mapping 变化时重新构建路由表，移除的前缀响应404，新增的前缀按函数名处理
+// 校验失败时保留当前配置，打印错误报告
+//
+// 替换后调用 OnConfigReloaded 监听器，可以使用 boot.CurrentConfig() 获取最新配置
+// boot.Config 始终是启动时的配置
+// /////////////////////////////////////////////////////////
+
+// 默认热加载检查间隔，单位秒
+const DefaultHotReloadInterval int = 2
+
+// 配置热加载
+type HotReload struct {
+	Enable   bool `yaml:"enable"`
+	Interval int  `yaml:"interval"` // 检查配置文件变化的间隔，单位秒
+}
+
+// 运行时可替换的配置，对应 goboot.server 下的yaml名称
+var hotReloadableKeys = map[string]bool{
+	"proxy":           true,
+	"cors":            true,
+	"gzip":            true,
+	"fileServer":      true,
+	"staticResources": true,
+	"mapping":         true,
+}
+
+// 可在运行时替换的处理器
+// 注册到gin后，通过 Swap 替换实际执行的处理函数
+type SwappableHandler struct {
+	handler atomic.Value
+}
+
+// 创建可替换的处理器
+func NewSwappableHandler(handler gin.HandlerFunc) *SwappableHandler {
+	ret := &SwappableHandler{}
+	ret.Swap(handler)
+	return ret
+}
+
+// 替换处理函数，为nil时直接继续执行
+func (swappable *SwappableHandler) Swap(handler gin.HandlerFunc) {
+	if handler == nil {
+		handler = NextHandler
+	}
+	swappable.handler.Store(handler)
+}
+
+// 执行当前的处理函数
+func (swappable *SwappableHandler) Handle(c *gin.Context) {
+	swappable.handler.Load().(gin.HandlerFunc)(c)
+}
+
+// 应用的热加载状态
+type configReloadState struct {
+	lock     sync.Mutex
+	current  atomic.Value
+	handlers map[string]*SwappableHandler
+	pending  []string
+	stop     chan struct{}
+}
+
+// 注册可替换的处理器，返回用于gin的处理函数
+func (boot *GobootApplication) runtimeHandler(name string, handler gin.HandlerFunc) gin.HandlerFunc {
+	if boot.reload.handlers == nil {
+		boot.reload.handlers = map[string]*SwappableHandler{}
+	}
+	swappable := NewSwappableHandler(handler)
+	boot.reload.handlers[name] = swappable
+	return swappable.Handle
+}
+
+// 替换已注册的处理器
+func (boot *GobootApplication) swapRuntimeHandler(name string, handler gin.HandlerFunc) {
+	if swappable, ok := boot.reload.handlers[name]; ok {
+		swappable.Swap(handler)
+	}
+}
+
+// 获取当前生效的配置
+// 未发生热加载时与 boot.Config 相同
+func (boot *GobootApplication) CurrentConfig() *GobootConfig {
+	if config, ok := boot.reload.current.Load().(*GobootConfig); ok {
+		return config
+	}
+	return boot.Config
+}
+
+// 获取已经变化但需要重启才能生效的配置
+func (boot *GobootApplication) PendingRestartKeys() []string {
+	boot.reload.lock.Lock()
+	defer boot.reload.lock.Unlock()
+	return append([]string{}, boot.reload.pending...)
+}
+
+// 重新加载配置文件，替换运行时安全的配置
+// 配置存在错误时保留当前配置并返回错误
+func (boot *GobootApplication) ReloadConfig() error {
+	boot.reload.lock.Lock()
+	defer boot.reload.lock.Unlock()
+
+	current := boot.CurrentConfig()
+	if current.ConfigFile == "" {
+		return fmt.Errorf("goboot reload config failure, application not loaded from config file")
+	}
+	LogInfo("goboot reload config, file: %v", current.ConfigFile)
+
+	loaded := ResolveGobootConfig(current.ConfigFile)
+	// 代码中设置的嵌入文件系统不来自配置文件
+	loaded.Goboot.Server.FileServer.EmbedStaticFs = current.Goboot.Server.FileServer.EmbedStaticFs
+	errs := ValidateGobootConfig(loaded)
+	if len(errs) > 0 {
+		LogConfigReport(errs)
+		return fmt.Errorf("goboot reload config failure, %v error(s), keep current config", len(errs))
+	}
+
+	// Run 之后重新构建mapping的路由表，存在错误时保留当前配置
+	var mappings *mappingTable
+	if boot.mappings.Load() != nil && !reflect.DeepEqual(current.Goboot.Server.Mapping, loaded.Goboot.Server.Mapping) {
+		table, errs := boot.buildMappingTable(loaded.Goboot.Server.Mapping)
+		if len(errs) > 0 {
+			for _, item := range errs {
+				LogError("%v", item)
+			}
+			return fmt.Errorf("goboot reload config failure, %v mapping error(s), keep current config", len(errs))
+		}
+		mappings = table
+	}
+
+	// 以当前配置为基础，只替换运行时安全的配置
+	next := *current
+	next.ConfigFiles = loaded.ConfigFiles
+	next.ConfigSources = loaded.ConfigSources
+	next.Properties = loaded.Properties
+	next.ConfigErrors = loaded.ConfigErrors
+	nextServer := &next.Goboot.Server
+	loadedServer := loaded.Goboot.Server
+	nextServer.Proxy = loadedServer.Proxy
+	nextServer.Cors = loadedServer.Cors
+	nextServer.Gzip = loadedServer.Gzip
+	nextServer.FileServer = loadedServer.FileServer
+	nextServer.StaticResources = loadedServer.StaticResources
+	nextServer.Mapping = loadedServer.Mapping
+	next.Goboot.Logging.Level = loaded.Goboot.Logging.Level
+	next.Goboot.Logging.Levels = loaded.Goboot.Logging.Levels
+
+	// 日志级别已经校验过，不会出错
+	ApplyLogLevels(next.Goboot.Logging)
+
+	boot.swapRuntimeHandler("cors", CorsMiddleware(nextServer.Cors))
+	boot.swapRuntimeHandler("gzip", GzipMiddleware(nextServer.Gzip))
+	boot.swapRuntimeHandler("static", StaticResourcesMiddleware(nextServer.StaticResources))
+	boot.swapRuntimeHandler("staticTryFiles", StaticTryFilesHandler(nextServer.StaticResources))
+	boot.swapRuntimeHandler("fileServer", FileServerMiddleware(nextServer.FileServer))
+	boot.swapRuntimeHandler("proxy", ProxyMiddleware(nextServer.Proxy))
+	if mappings != nil {
+		boot.mappings.Store(mappings)
+	}
+
+	boot.reload.current.Store(&next)
+
+	// 与启动时的配置比较，记录需要重启的配置
+	boot.reload.pending = diffRestartRequiredKeys(boot.Config, loaded)
+	for _, item := range boot.reload.pending {
+		LogWarn("goboot config %v changed, require restart to take effect", item)
+	}
+
+	LogInfo("goboot config reloaded.")
+	invokeListeners(boot, boot.Listeners.OnConfigReloaded)
+	return nil
+}
+
+// 比较不能在运行时替换的配置，返回变化的配置键
+func diffRestartRequiredKeys(origin *GobootConfig, loaded *GobootConfig) []string {
+	ret := []string{}
+	if !reflect.DeepEqual(origin.Goboot.Application, loaded.Goboot.Application) {
+		ret = append(ret, "goboot.application")
+	}
+	if !reflect.DeepEqual(origin.Goboot.Profiles, loaded.Goboot.Profiles) {
+		ret = append(ret, "goboot.profiles")
+	}
+	// 日志级别可以替换，其他日志配置需要重启
+	originLogging := origin.Goboot.Logging
+	loadedLogging := loaded.Goboot.Logging
+	originLogging.Level, originLogging.Levels = "", nil
+	loadedLogging.Level, loadedLogging.Levels = "", nil
+	if !reflect.DeepEqual(originLogging, loadedLogging) {
+		ret = append(ret, "goboot.logging")
+	}
+	originValue := reflect.ValueOf(origin.Goboot.Server)
+	loadedValue := reflect.ValueOf(loaded.Goboot.Server)
+	rtype := originValue.Type()
+	for i := 0; i < rtype.NumField(); i++ {
+		name := strings.Split(rtype.Field(i).Tag.Get("yaml"), ",")[0]
+		if hotReloadableKeys[name] {
+			continue
+		}
+		if !reflect.DeepEqual(originValue.Field(i).Interface(), loadedValue.Field(i).Interface()) {
+			ret = append(ret, "goboot.server."+name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// 获取需要监听的配置文件
+// 包含激活环境尚不存在的配置文件，以便创建后也能加载
+func hotReloadWatchFiles(config *GobootConfig) []string {
+	ret := []string{config.ConfigFile}
+	for _, profile := range ParseActiveProfiles(config.Goboot.Profiles.Active) {
+		ret = append(ret, ProfileConfigFile(config.ConfigFile, profile))
+	}
+	return ret
+}
+
+// 获取文件的修改标识，文件不存在时为空
+func hotReloadFileStamps(files []string) map[string]string {
+	ret := map[string]string{}
+	for _, item := range files {
+		info, err := os.Stat(item)
+		if err != nil {
+			ret[item] = ""
+			continue
+		}
+		ret[item] = fmt.Sprintf("%v:%v", info.ModTime().UnixNano(), info.Size())
+	}
+	return ret
+}
+
+// 启动配置文件监听，开启热加载时在 Run 中调用
+func (boot *GobootApplication) startConfigWatcher() {
+	hotReload := boot.Config.Goboot.Server.HotReload
+	if !hotReload.Enable || boot.Config.ConfigFile == "" {
+		return
+	}
+	interval := hotReload.Interval
+	if interval <= 0 {
+		interval = DefaultHotReloadInterval
+	}
+	files := hotReloadWatchFiles(boot.Config)
+	LogInfo("goboot enable config hot reload, interval %vs, watch: %v", interval, strings.Join(files, ", "))
+
+	boot.reload.stop = make(chan struct{})
+	stop := boot.reload.stop
+	stamps := hotReloadFileStamps(files)
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				next := hotReloadFileStamps(files)
+				if reflect.DeepEqual(stamps, next) {
+					continue
+				}
+				stamps = next
+				err := boot.ReloadConfig()
+				if err != nil {
+					LogError("%v", err)
+				}
+			}
+		}
+	}()
+}
+
+// 停止配置文件监听
+func (boot *GobootApplication) stopConfigWatcher() {
+	if boot.reload.stop != nil {
+		close(boot.reload.stop)
+		boot.reload.stop = nil
+	}
+}
@@ -0,0 +1,136 @@
+package goboot
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSwappableHandler(t *testing.T) {
+	calls := []string{}
+	swappable := NewSwappableHandler(func(c *gin.Context) {
+		calls = append(calls, "first")
+	})
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	swappable.Handle(c)
+	swappable.Swap(func(c *gin.Context) {
+		calls = append(calls, "second")
+	})
+	swappable.Handle(c)
+	swappable.Swap(nil)
+	swappable.Handle(c)
+	if !reflect.DeepEqual(calls, []string{"first", "second"}) {
+		t.Fatalf("calls = %v", calls)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "goboot.yml")
+	write := func(content string) {
+		if err := os.WriteFile(cfgFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("goboot:\n  server:\n    port: 8080\n    cors:\n      enable: true\n      allowOrigins: [http://a.com]\n")
+
+	reloaded := 0
+	listener := &GobootLifecycleListener{
+		OnConfigReloaded: []GobootListener{func(boot *GobootApplication) { reloaded++ }},
+	}
+	boot := GetApplication(cfgFile, listener)
+
+	write("goboot:\n  server:\n    port: 9090\n    cors:\n      enable: true\n      allowOrigins: [http://b.com]\n")
+	if err := boot.ReloadConfig(); err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if got := boot.CurrentConfig().Goboot.Server.Cors.AllowOrigins; !reflect.DeepEqual(got, []string{"http://b.com"}) {
+		t.Fatalf("current cors origins = %v", got)
+	}
+	if boot.CurrentConfig().Goboot.Server.Port != 8080 || boot.Config.Goboot.Server.Port != 8080 {
+		t.Fatalf("port should keep 8080 until restart")
+	}
+	if got := boot.PendingRestartKeys(); !reflect.DeepEqual(got, []string{"goboot.server.port"}) {
+		t.Fatalf("pending restart keys = %v", got)
+	}
+	if reloaded != 1 {
+		t.Fatalf("reloaded listener called %v time(s)", reloaded)
+	}
+
+	// 新配置有误时保留当前配置
+	write("goboot:\n  server:\n    port: 9090\n    cros:\n      enable: true\n")
+	if err := boot.ReloadConfig(); err == nil {
+		t.Fatalf("expected reload error")
+	}
+	if got := boot.CurrentConfig().Goboot.Server.Cors.AllowOrigins; !reflect.DeepEqual(got, []string{"http://b.com"}) {
+		t.Fatalf("current cors origins = %v after failed reload", got)
+	}
+}
+
+func TestReloadMappingPrefix(t *testing.T) {
+	resetTestLogging(t)
+	cfgFile := filepath.Join(t.TempDir(), "goboot.yml")
+	write := func(content string) {
+		if err := os.WriteFile(cfgFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("goboot:\n  server:\n    port: 8080\n    mapping:\n      enable: true\n      items: [/api/]\n")
+	boot := GetApplication(cfgFile, nil)
+	boot.AddHandlers(&mappingTestApi{})
+	boot.compileMappings()
+	if rec := serveMappingTest(boot, "GET", "/api/user/info"); rec.Code != 200 || !strings.Contains(rec.Body.String(), "info") {
+		t.Fatalf("before reload code = %v, body = %v", rec.Code, rec.Body.String())
+	}
+
+	write("goboot:\n  server:\n    port: 8080\n    mapping:\n      enable: true\n      items: [/v2/]\n")
+	if err := boot.ReloadConfig(); err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if got := boot.PendingRestartKeys(); len(got) != 0 {
+		t.Fatalf("pending restart keys = %v", got)
+	}
+	// 移除的前缀已经注册为gin路由，仍然响应404
+	for _, target := range []string{"/api/user/info", "/api/User_Info"} {
+		if rec := serveMappingTest(boot, "GET", target); rec.Code != 404 {
+			t.Fatalf("GET %v code = %v after reload", target, rec.Code)
+		}
+	}
+	if rec := serveMappingTest(boot, "GET", "/v2/user/info"); rec.Code != 200 || !strings.Contains(rec.Body.String(), "info") {
+		t.Fatalf("GET /v2/user/info code = %v, body = %v", rec.Code, rec.Body.String())
+	}
+	if rec := serveMappingTest(boot, "POST", "/v2/findUser/geoRange"); rec.Code != 200 || !strings.Contains(rec.Body.String(), "geo") {
+		t.Fatalf("POST /v2/findUser/geoRange code = %v, body = %v", rec.Code, rec.Body.String())
+	}
+	if rec := serveMappingTest(boot, "DELETE", "/v2/user/info"); rec.Code != 405 {
+		t.Fatalf("DELETE /v2/user/info code = %v", rec.Code)
+	}
+
+	// 关闭mapping后所有前缀响应404
+	write("goboot:\n  server:\n    port: 8080\n    mapping:\n      enable: false\n      items: [/v2/]\n")
+	if err := boot.ReloadConfig(); err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if rec := serveMappingTest(boot, "GET", "/v2/user/info"); rec.Code != 404 {
+		t.Fatalf("GET /v2/user/info code = %v after disable", rec.Code)
+	}
+}
+
+func TestDiffRestartRequiredKeys(t *testing.T) {
+	origin := &GobootConfig{}
+	loaded := &GobootConfig{}
+	loaded.Goboot.Logging.Level = "debug"
+	loaded.Goboot.Server.Gzip.Enable = true
+	loaded.Goboot.Server.Mapping.Enable = true
+	loaded.Goboot.Server.Datasource.Host = "db"
+
+	got := diffRestartRequiredKeys(origin, loaded)
+	want := []string{"goboot.server.datasource"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,644 @@
+package goboot
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 配置校验区
+// 配置转换到结构时，goboot 节点下不认识的键会被视为错误，避免拼写错误被静默忽略
+// 之后对配置进行校验，包括端口，文件路径，TLS文件，驱动名称以及功能之间的依赖
+// 所有错误汇总后一次性打印，应用启动时存在错误则退出
+// /////////////////////////////////////////////////////////
+
+// 已支持的数据源驱动
+var supportDatasourceDrivers = []string{"mysql", "postgres"}
+
+// 已支持的session实现
+var supportSessionImpls = []string{"cookie", "redis"}
+
+// 已支持的令牌存储
+var supportAuthStores = []string{"memory", "redis", "db"}
+
+// 已支持的JWT刷新令牌存储
+var supportJwtRefreshStores = []string{"memory", "redis"}
+
+// 示例配置中公开的JWT密钥，不能用于签发令牌
+var knownJwtSecrets = []string{"please-change-this-jwt-secret-key"}
+
+// 已支持的限流存储
+var supportRateLimitStores = []string{"memory", "redis"}
+
+// 已支持的限流算法
+var supportRateLimitAlgorithms = []string{RateLimitTokenBucket, RateLimitSlidingWindow}
+
+// 已支持的gzip压缩级别
+var supportGzipLevels = []string{"BestCompression", "BestSpeed", "DefaultCompression", "NoCompression"}
+
+var yamlErrorLineRegex = regexp.MustCompile(`^line \d+: `)
+
+// 将合并后的配置map转换到配置结构
+// 返回 goboot 节点下不认识的键以及类型错误
+func decodeGobootConfigMap(cfgMap map[string]interface{}, sources map[string]string, config *GobootConfig) []string {
+	errs := checkConfigKeys(cfgMap["goboot"], reflect.TypeOf(config.Goboot), "goboot", sources)
+
+	err := decodeConfigNode(cfgMap, config)
+	if err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			// 已经检查出带路径的错误时，不再重复
+			if len(errs) > 0 {
+				return errs
+			}
+			// 重新序列化后的行号没有意义，去掉行号
+			for _, item := range typeErr.Errors {
+				errs = append(errs, yamlErrorLineRegex.ReplaceAllString(item, ""))
+			}
+		} else {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
+
+// 将配置map中的节点转换到结构
+// 占位符替换的结果是字符串，字段为数值或布尔时按字段类型转换
+func decodeConfigNode(node interface{}, target interface{}) error {
+	var yamlNode yaml.Node
+	err := yamlNode.Encode(node)
+	if err != nil {
+		return err
+	}
+	convertConfigScalars(&yamlNode, reflect.TypeOf(target))
+	return yamlNode.Decode(target)
+}
+
+// 按照结构的字段类型，转换字符串节点的标签
+func convertConfigScalars(node *yaml.Node, rtype reflect.Type) {
+	for rtype.Kind() == reflect.Ptr {
+		rtype = rtype.Elem()
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		switch rtype.Kind() {
+		case reflect.Struct:
+			fields := configStructFields(rtype)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if ftype, ok := fields[node.Content[i].Value]; ok {
+					convertConfigScalars(node.Content[i+1], ftype)
+				}
+			}
+		case reflect.Map:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				convertConfigScalars(node.Content[i+1], rtype.Elem())
+			}
+		}
+	case yaml.SequenceNode:
+		if rtype.Kind() == reflect.Slice || rtype.Kind() == reflect.Array {
+			for _, item := range node.Content {
+				convertConfigScalars(item, rtype.Elem())
+			}
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			return
+		}
+		if tag, value, ok := convertConfigScalar(node.Value, rtype.Kind()); ok {
+			node.Tag = tag
+			node.Value = value
+			node.Style = 0
+		}
+	}
+}
+
+// 将字符串转换为数值或布尔节点的值，不能转换时返回false
+func convertConfigScalar(str string, kind reflect.Kind) (string, string, bool) {
+	str = strings.TrimSpace(str)
+	switch kind {
+	case reflect.Bool:
+		if val, err := strconv.ParseBool(str); err == nil {
+			return "!!bool", strconv.FormatBool(val), true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if val, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return "!!int", strconv.FormatInt(val, 10), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if val, err := strconv.ParseUint(str, 10, 64); err == nil {
+			return "!!int", strconv.FormatUint(val, 10), true
+		}
+	case reflect.Float32, reflect.Float64:
+		if val, err := strconv.ParseFloat(str, 64); err == nil {
+			return "!!float", strconv.FormatFloat(val, 'g', -1, 64), true
+		}
+	}
+	return "", "", false
+}
+
+// 结构体字段的yaml名称到类型的映射
+func configStructFields(rtype reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < rtype.NumField(); i++ {
+		field := rtype.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// 检查配置中不认识的键以及基础值的类型
+func checkConfigKeys(node interface{}, rtype reflect.Type, keyPath string, sources map[string]string) []string {
+	errs := []string{}
+	for rtype.Kind() == reflect.Ptr {
+		rtype = rtype.Elem()
+	}
+	switch rtype.Kind() {
+	case reflect.Struct:
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			return errs
+		}
+		fields := configStructFields(rtype)
+		for _, key := range sortedConfigKeys(nodeMap) {
+			item := nodeMap[key]
+			itemPath := joinConfigKey(keyPath, key)
+			ftype, ok := fields[key]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("%v: unknown config key%v", itemPath, findConfigSourceText(sources, itemPath)))
+				continue
+			}
+			errs = append(errs, checkConfigKeys(item, ftype, itemPath, sources)...)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := node.([]interface{})
+		if !ok {
+			return errs
+		}
+		for i, item := range list {
+			errs = append(errs, checkConfigKeys(item, rtype.Elem(), joinConfigKey(keyPath, fmt.Sprint(i)), sources)...)
+		}
+	case reflect.Map:
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			return errs
+		}
+		for _, key := range sortedConfigKeys(nodeMap) {
+			errs = append(errs, checkConfigKeys(nodeMap[key], rtype.Elem(), joinConfigKey(keyPath, key), sources)...)
+		}
+	case reflect.Bool:
+		if str, ok := node.(string); ok {
+			if _, _, ok := convertConfigScalar(str, rtype.Kind()); ok {
+				return errs
+			}
+		}
+		if _, ok := node.(bool); !ok && node != nil {
+			errs = append(errs, fmt.Sprintf("%v: require bool, but got %v%v", keyPath, node, findConfigSourceText(sources, keyPath)))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch val := node.(type) {
+		case int, int64, uint64, nil:
+		case string:
+			if _, _, ok := convertConfigScalar(val, rtype.Kind()); !ok {
+				errs = append(errs, fmt.Sprintf("%v: require integer, but got %v%v", keyPath, node, findConfigSourceText(sources, keyPath)))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("%v: require integer, but got %v%v", keyPath, node, findConfigSourceText(sources, keyPath)))
+		}
+	}
+	return errs
+}
+
+// 获取排序后的键，使得错误信息的顺序固定
+func sortedConfigKeys(nodeMap map[string]interface{}) []string {
+	keys := make([]string, 0, len(nodeMap))
+	for key := range nodeMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// 查找配置项的来源文件描述
+func findConfigSourceText(sources map[string]string, keyPath string) string {
+	if source, ok := sources[keyPath]; ok {
+		return fmt.Sprintf(" (in %v)", source)
+	}
+	for key, source := range sources {
+		if strings.HasPrefix(key, keyPath+".") {
+			return fmt.Sprintf(" (in %v)", source)
+		}
+	}
+	return ""
+}
+
+// 校验配置，返回所有的错误信息
+// 包含配置解析时发现的错误
+func ValidateGobootConfig(config *GobootConfig) []string {
+	errs := append([]string{}, config.ConfigErrors...)
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+	checkPort := func(key string, port int, allowZero bool) {
+		if port == 0 && allowZero {
+			return
+		}
+		if port <= 0 || port > 65535 {
+			addErr("%v: invalid port %v, require 1-65535", key, port)
+		}
+	}
+	checkFile := func(key string, filePath string) {
+		if filePath == "" {
+			addErr("%v: require file path", key)
+			return
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			addErr("%v: file %v not accessible, %v", key, filePath, err)
+		} else if info.IsDir() {
+			addErr("%v: %v is directory, require file", key, filePath)
+		}
+	}
+	checkPathPatterns := func(key string, patterns []string) {
+		for i, item := range patterns {
+			if !strings.HasPrefix(item, "/") {
+				addErr("%v.%v: %v require start with /", key, i, item)
+				continue
+			}
+			if _, err := path.Match(item, ""); err != nil {
+				addErr("%v.%v: invalid pattern %v, %v", key, i, item, err)
+			}
+		}
+	}
+
+	goboot := config.Goboot
+	server := goboot.Server
+
+	if goboot.Profiles.ListMerge != "" && !SliceContains([]string{ConfigListMergeReplace, ConfigListMergeAppend}, goboot.Profiles.ListMerge) {
+		addErr("goboot.profiles.listMerge: un-support value %v, require %v/%v", goboot.Profiles.ListMerge, ConfigListMergeReplace, ConfigListMergeAppend)
+	}
+
+	// 日志
+	logging := goboot.Logging
+	if logging.Level != "" {
+		if _, err := ParseLogLevel(logging.Level); err != nil {
+			addErr("goboot.logging.level: %v, require debug/info/warn/error", err)
+		}
+	}
+	levelNames := []string{}
+	for name := range logging.Levels {
+		levelNames = append(levelNames, name)
+	}
+	sort.Strings(levelNames)
+	for _, name := range levelNames {
+		if _, err := ParseLogLevel(logging.Levels[name]); err != nil {
+			addErr("goboot.logging.levels.%v: %v, require debug/info/warn/error", name, err)
+		}
+	}
+	if logging.Format != "" && !SliceContains([]string{"console", "text", "json"}, strings.ToLower(logging.Format)) {
+		addErr("goboot.logging.format: un-support value %v, require console/text/json", logging.Format)
+	}
+	if logging.File.Enable {
+		if logging.File.Path == "" {
+			addErr("goboot.logging.file.path: require file path when file enabled")
+		}
+		if logging.File.MaxSize < 0 || logging.File.MaxBackups < 0 || logging.File.MaxAge < 0 {
+			addErr("goboot.logging.file: maxSize/maxBackups/maxAge require >= 0")
+		}
+	}
+
+	checkPort("goboot.server.port", server.Port, false)
+	for i, item := range server.TrustedProxies {
+		if net.ParseIP(item) == nil {
+			if _, _, err := net.ParseCIDR(item); err != nil {
+				addErr("goboot.server.trustedProxies.%v: invalid ip or cidr %v", i, item)
+			}
+		}
+	}
+	if server.ShutdownTimeout < 0 {
+		addErr("goboot.server.shutdownTimeout: invalid value %v, require >= 0", server.ShutdownTimeout)
+	}
+	if server.HotReload.Interval < 0 {
+		addErr("goboot.server.hotReload.interval: invalid value %v, require >= 0", server.HotReload.Interval)
+	}
+
+	// 管理端点
+	if server.Management.Enable {
+		checkPort("goboot.server.management.port", server.Management.Port, true)
+		if server.Management.Token == "" {
+			addErr("goboot.server.management.token: require token when management enabled")
+		}
+	}
+
+	// OpenAPI文档
+	if server.OpenApi.Enable {
+		if server.OpenApi.Path != "" && !strings.HasPrefix(server.OpenApi.Path, "/") {
+			addErr("goboot.server.openapi.path: %v require start with /", server.OpenApi.Path)
+		}
+		if server.OpenApi.UiPath != "" && !strings.HasPrefix(server.OpenApi.UiPath, "/") {
+			addErr("goboot.server.openapi.uiPath: %v require start with /", server.OpenApi.UiPath)
+		}
+	}
+
+	// 令牌认证
+	if server.Auth.Enable {
+		if server.Auth.Store != "" && !SliceContains(supportAuthStores, server.Auth.Store) {
+			addErr("goboot.server.auth.store: un-support value %v, require %v", server.Auth.Store, strings.Join(supportAuthStores, "/"))
+		}
+		if server.Auth.Store == "redis" && !server.Redis.Enable {
+			addErr("goboot.server.auth.store: redis store require enable redis config [goboot.server.redis.enable]")
+		}
+		if server.Auth.Store == "db" && !server.Datasource.Enable {
+			addErr("goboot.server.auth.store: db store require enable datasource config [goboot.server.datasource.enable]")
+		}
+		if server.Auth.Expire < 0 {
+			addErr("goboot.server.auth.expire: invalid value %v, require >= 0", server.Auth.Expire)
+		}
+		checkPathPatterns("goboot.server.auth.includes", server.Auth.Includes)
+		checkPathPatterns("goboot.server.auth.excludes", server.Auth.Excludes)
+	}
+
+	// JWT
+	if server.Jwt.Enable {
+		if server.Jwt.RefreshStore != "" && !SliceContains(supportJwtRefreshStores, server.Jwt.RefreshStore) {
+			addErr("goboot.server.jwt.refreshStore: un-support value %v, require %v", server.Jwt.RefreshStore, strings.Join(supportJwtRefreshStores, "/"))
+		}
+		if server.Jwt.RefreshStore == "redis" && !server.Redis.Enable {
+			addErr("goboot.server.jwt.refreshStore: redis store require enable redis config [goboot.server.redis.enable]")
+		}
+		if server.Jwt.Expire < 0 {
+			addErr("goboot.server.jwt.expire: invalid value %v, require >= 0", server.Jwt.Expire)
+		}
+		if server.Jwt.RefreshExpire < 0 {
+			addErr("goboot.server.jwt.refreshExpire: invalid value %v, require >= 0", server.Jwt.RefreshExpire)
+		}
+		if server.Jwt.Leeway < 0 {
+			addErr("goboot.server.jwt.leeway: invalid value %v, require >= 0", server.Jwt.Leeway)
+		}
+		if len(server.Jwt.Keys) == 0 {
+			addErr("goboot.server.jwt.keys: require at least one key")
+		}
+		kids := map[string]bool{}
+		for i, item := range server.Jwt.Keys {
+			key := fmt.Sprintf("goboot.server.jwt.keys.%v", i)
+			if kids[item.Kid] {
+				addErr("%v.kid: duplicate kid %v", key, item.Kid)
+			}
+			kids[item.Kid] = true
+			hash, ok := jwtAlgHashes[item.Alg]
+			if !ok {
+				addErr("%v.alg: un-support value %v", key, item.Alg)
+				continue
+			}
+			if strings.HasPrefix(item.Alg, "HS") {
+				if len(item.Secret) < hash.Size() {
+					addErr("%v.secret: %v require secret at least %v bytes", key, item.Alg, hash.Size())
+				} else if SliceContains(knownJwtSecrets, item.Secret) {
+					addErr("%v.secret: default secret is public, require a private secret, e.g. ${JWT_SECRET}", key)
+				}
+				continue
+			}
+			if item.PrivateKeyPath == "" && item.PublicKeyPath == "" {
+				addErr("%v: %v require privateKeyPath or publicKeyPath", key, item.Alg)
+			}
+			if item.PrivateKeyPath != "" {
+				checkFile(key+".privateKeyPath", item.PrivateKeyPath)
+			}
+			if item.PublicKeyPath != "" {
+				checkFile(key+".publicKeyPath", item.PublicKeyPath)
+			}
+		}
+		if server.Jwt.ActiveKey != "" && !kids[server.Jwt.ActiveKey] {
+			addErr("goboot.server.jwt.activeKey: %v not found in keys", server.Jwt.ActiveKey)
+		}
+		checkPathPatterns("goboot.server.jwt.includes", server.Jwt.Includes)
+		checkPathPatterns("goboot.server.jwt.excludes", server.Jwt.Excludes)
+	}
+
+	// 权限
+	if server.Permission.CacheExpire < 0 {
+		addErr("goboot.server.permission.cacheExpire: invalid value %v, require >= 0", server.Permission.CacheExpire)
+	}
+	if server.Permission.CacheExpire > 0 && !server.Redis.Enable {
+		addErr("goboot.server.permission.cacheExpire: cache require enable redis config [goboot.server.redis.enable]")
+	}
+
+	// 限流
+	if server.RateLimit.Enable {
+		if server.RateLimit.Store != "" && !SliceContains(supportRateLimitStores, server.RateLimit.Store) {
+			addErr("goboot.server.rateLimit.store: un-support value %v, require %v", server.RateLimit.Store, strings.Join(supportRateLimitStores, "/"))
+		}
+		if server.RateLimit.Store == "redis" && !server.Redis.Enable {
+			addErr("goboot.server.rateLimit.store: redis store require enable redis config [goboot.server.redis.enable]")
+		}
+		names := map[string]bool{}
+		for i, item := range server.RateLimit.Rules {
+			key := fmt.Sprintf("goboot.server.rateLimit.rules.%v", i)
+			if item.Name != "" {
+				if names[item.Name] {
+					addErr("%v.name: duplicate name %v", key, item.Name)
+				}
+				names[item.Name] = true
+			}
+			if item.Limit <= 0 {
+				addErr("%v.limit: invalid value %v, require > 0", key, item.Limit)
+			}
+			if item.Window <= 0 {
+				addErr("%v.window: invalid value %v, require > 0", key, item.Window)
+			}
+			if item.Algorithm != "" && !SliceContains(supportRateLimitAlgorithms, item.Algorithm) {
+				addErr("%v.algorithm: un-support value %v, require %v", key, item.Algorithm, strings.Join(supportRateLimitAlgorithms, "/"))
+			}
+			if item.Key != "" && item.Key != "ip" && item.Key != "token" && item.Key != "global" && (!strings.HasPrefix(item.Key, "header:") || item.Key == "header:") {
+				addErr("%v.key: un-support value %v, require ip/token/global/header:{name}", key, item.Key)
+			}
+			checkPathPatterns(key+".paths", item.Paths)
+			for j, pattern := range item.Methods {
+				if _, err := path.Match(pattern, ""); err != nil {
+					addErr("%v.methods.%v: invalid pattern %v, %v", key, j, pattern, err)
+				}
+			}
+		}
+	}
+
+	// 指标
+	if server.Metrics.Enable {
+		if !server.Metrics.Public && server.Management.Token == "" {
+			addErr("goboot.server.metrics: require management token [goboot.server.management.token], or set metrics.public to true")
+		}
+		for i, item := range server.Metrics.Buckets {
+			if item <= 0 {
+				addErr("goboot.server.metrics.buckets.%v: invalid value %v, require > 0", i, item)
+			}
+		}
+	}
+
+	// https
+	if server.Https.Enable {
+		if !server.Https.SelfSigned {
+			checkFile("goboot.server.https.pemPath", server.Https.PemPath)
+			checkFile("goboot.server.https.keyPath", server.Https.KeyPath)
+		} else if server.Https.PemPath == "" || server.Https.KeyPath == "" {
+			addErr("goboot.server.https: self-signed require pemPath and keyPath to save certificate")
+		}
+		if server.Https.MinVersion != "" {
+			if _, ok := tlsVersionNames[strings.ToUpper(server.Https.MinVersion)]; !ok {
+				addErr("goboot.server.https.minVersion: un-support tls version %v", server.Https.MinVersion)
+			}
+		}
+		suites := tlsCipherSuiteIds()
+		for i, name := range server.Https.CipherSuites {
+			if _, ok := suites[name]; !ok {
+				addErr("goboot.server.https.cipherSuites.%v: un-support cipher suite %v", i, name)
+			}
+		}
+		checkPort("goboot.server.https.redirectHttpPort", server.Https.RedirectHttpPort, true)
+		if server.Https.RedirectHttpPort == server.Port {
+			addErr("goboot.server.https.redirectHttpPort: can not be same as goboot.server.port %v", server.Port)
+		}
+	}
+
+	// 模板
+	if server.TemplateResources.Enable {
+		if server.TemplateResources.FilePath == "" {
+			addErr("goboot.server.templateResources.filePath: require template file pattern")
+		} else if matches, err := filepath.Glob(server.TemplateResources.FilePath); err != nil {
+			addErr("goboot.server.templateResources.filePath: invalid pattern %v, %v", server.TemplateResources.FilePath, err)
+		} else if len(matches) == 0 {
+			addErr("goboot.server.templateResources.filePath: pattern %v not match any file", server.TemplateResources.FilePath)
+		}
+	}
+
+	// 静态资源
+	if server.StaticResources.Enable {
+		for i, item := range server.StaticResources.Items {
+			key := fmt.Sprintf("goboot.server.staticResources.items.%v", i)
+			if !strings.HasPrefix(item.UrlPath, "/") {
+				addErr("%v.urlPath: require start with /, but got %v", key, item.UrlPath)
+			}
+			if item.FilePath == "" {
+				addErr("%v.filePath: require directory path", key)
+			}
+		}
+	}
+
+	// session
+	if server.Session.Enable {
+		if server.Session.Impl != "" && !SliceContains(supportSessionImpls, server.Session.Impl) {
+			addErr("goboot.server.session.impl: un-support value %v, require %v", server.Session.Impl, strings.Join(supportSessionImpls, "/"))
+		}
+		if server.Session.Impl == "redis" && !server.Redis.Enable {
+			addErr("goboot.server.session.impl: redis session require enable redis config [goboot.server.redis.enable]")
+		}
+		if server.Session.SecretKey == "" {
+			addErr("goboot.server.session.secretKey: require secret key")
+		}
+	}
+
+	// redis
+	if server.Redis.Enable {
+		checkPort("goboot.server.redis.port", server.Redis.Port, true)
+		if server.Redis.Database < 0 {
+			addErr("goboot.server.redis.database: invalid database %v", server.Redis.Database)
+		}
+	}
+
+	// 数据源
+	if server.Datasource.Enable {
+		if !SliceContains(supportDatasourceDrivers, server.Datasource.Driver) {
+			addErr("goboot.server.datasource.driver: un-support driver %v, require %v", server.Datasource.Driver, strings.Join(supportDatasourceDrivers, "/"))
+		}
+		if server.Datasource.Url == "" {
+			checkPort("goboot.server.datasource.port", server.Datasource.Port, true)
+			if server.Datasource.Database == "" {
+				addErr("goboot.server.datasource.database: require database when url is empty")
+			}
+		}
+	}
+	if server.Gorm.Enable && !server.Datasource.Enable {
+		addErr("goboot.server.gorm.enable: gorm require enable datasource config [goboot.server.datasource.enable]")
+	}
+
+	// gzip
+	if server.Gzip.Enable {
+		if server.Gzip.Level != "" && !SliceContains(supportGzipLevels, server.Gzip.Level) {
+			addErr("goboot.server.gzip.level: un-support level %v, require %v", server.Gzip.Level, strings.Join(supportGzipLevels, "/"))
+		}
+		for i, item := range server.Gzip.ExcludePathRegexes {
+			if _, err := regexp.Compile(item); err != nil {
+				addErr("goboot.server.gzip.excludePathRegexes.%v: invalid regex %v, %v", i, item, err)
+			}
+		}
+	}
+
+	// 代理
+	if server.Proxy.Enable {
+		for i, item := range server.Proxy.Items {
+			key := fmt.Sprintf("goboot.server.proxy.items.%v", i)
+			if !strings.HasPrefix(item.Path, "/") {
+				addErr("%v.path: require start with /, but got %v", key, item.Path)
+			}
+			remote, err := url.Parse(item.Redirect)
+			if err != nil || remote.Scheme == "" || remote.Host == "" {
+				addErr("%v.redirect: invalid url %v", key, item.Redirect)
+			}
+		}
+	}
+
+	// 映射
+	if server.Mapping.Enable {
+		for i, item := range server.Mapping.Items {
+			if !strings.HasPrefix(item, "/") {
+				addErr("goboot.server.mapping.items.%v: require start with /, but got %v", i, item)
+			}
+		}
+	}
+
+	// 文件服务器
+	if server.FileServer.Enable {
+		if server.FileServer.RootPath == "" {
+			addErr("goboot.server.fileServer.rootPath: require directory path")
+		} else if info, err := os.Stat(server.FileServer.RootPath); err != nil {
+			addErr("goboot.server.fileServer.rootPath: directory %v not accessible, %v", server.FileServer.RootPath, err)
+		} else if !info.IsDir() {
+			addErr("goboot.server.fileServer.rootPath: %v is not directory", server.FileServer.RootPath)
+		}
+	}
+
+	// 跨域
+	if server.Cors.Enable && !server.Cors.AllowAllOrigins && len(server.Cors.AllowOrigins) == 0 {
+		addErr("goboot.server.cors.allowOrigins: require allowOrigins when allowAllOrigins is false")
+	}
+
+	return errs
+}
+
+// 打印配置错误报告
+func LogConfigReport(errs []string) {
+	LogError("goboot config check failure, %v error(s):", len(errs))
+	for i, item := range errs {
+		LogError("  %v. %v", i+1, item)
+	}
+}
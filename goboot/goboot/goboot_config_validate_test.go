@@ -0,0 +1,76 @@
+package goboot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func containsConfigError(errs []string, key string) bool {
+	for _, item := range errs {
+		if strings.HasPrefix(item, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateGobootConfig(t *testing.T) {
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	if errs := ValidateGobootConfig(config); len(errs) > 0 {
+		t.Fatalf("errors for default config: %v", errs)
+	}
+
+	config.Goboot.Server.Port = 70000
+	config.Goboot.Logging.Level = "verbose"
+	config.Goboot.Server.Management.Enable = true
+	config.Goboot.Server.Auth.Enable = true
+	config.Goboot.Server.Auth.Store = "redis"
+	config.Goboot.Server.Auth.Includes = []string{"api/**"}
+	config.ConfigErrors = []string{"goboot.server.unknown: unknown config key"}
+
+	errs := ValidateGobootConfig(config)
+	for _, key := range []string{
+		"goboot.server.unknown",
+		"goboot.server.port",
+		"goboot.logging.level",
+		"goboot.server.management.token",
+		"goboot.server.auth.store",
+		"goboot.server.auth.includes.0",
+	} {
+		if !containsConfigError(errs, key) {
+			t.Errorf("missing error for %v in %v", key, errs)
+		}
+	}
+}
+
+func TestCheckConfigKeys(t *testing.T) {
+	cfgMap := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port":    "abc",
+			"prot":    8080,
+			"gzip":    map[string]interface{}{"enable": "yes"},
+			"mapping": map[string]interface{}{"items": []interface{}{"/api/"}},
+		},
+	}
+	sources := map[string]string{"goboot.server.prot": "goboot-dev.yml"}
+	errs := checkConfigKeys(cfgMap, reflect.TypeOf(Goboot{}), "goboot", sources)
+	want := []string{
+		"goboot.server.gzip.enable: require bool, but got yes",
+		"goboot.server.port: require integer, but got abc",
+		"goboot.server.prot: unknown config key (in goboot-dev.yml)",
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("errors = %v, want %v", errs, want)
+	}
+}
+
+func TestGetConfigApplicationDefersConfigErrors(t *testing.T) {
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = -1
+	boot := GetConfigApplication(config, nil)
+	if !containsConfigError(boot.startupErrors, "goboot.server.port") {
+		t.Fatalf("startup errors = %v", boot.startupErrors)
+	}
+}
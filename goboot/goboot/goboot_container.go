@@ -0,0 +1,432 @@
+package goboot
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 服务容器区
+// 按照类型注册服务，在 Run 之前注册
+// Provide 注册单例的构造函数，ProvideRequest 注册请求作用域的构造函数，Register 注册单例的实例
+// 构造函数为 func(deps...) T 或者 func(deps...) (T, error)，按照返回值类型注册
+// 构造函数的参数自动解析，可以是容器中的服务、BindConfig 绑定的配置
+// 以及 *GobootApplication, *gin.Engine, *RedisCli, *redis.Client, *gorm.DB, *sql.DB
+// 请求作用域的构造函数，还可以使用映射函数可注入的类型，例如 *gin.Context
+//
+// 处理器、控制器和服务中使用 inject 标签的导出字段，在启动时注入
+// type Api struct {
+// 	Users *UserService `inject:""`
+// }
+// 映射函数的参数也可以直接使用容器中的服务
+//
+// 单例在 Run 时创建，按照创建顺序调用 Init() error，Shutdown 时按照逆序调用 Close() error
+// 请求作用域的服务在同一个请求内只创建一次，创建时调用 Init，映射函数执行后调用 Close
+// 单例不能依赖请求作用域的服务，循环依赖和缺少的依赖在启动时报错
+// /////////////////////////////////////////////////////////
+
+// 服务的作用域
+type ServiceScope string
+
+const (
+	ServiceScopeSingleton ServiceScope = "singleton"
+	ServiceScopeRequest   ServiceScope = "request"
+)
+
+// 服务初始化，单例在 Run 时调用，请求作用域的服务在创建时调用
+type ServiceInitializer interface {
+	Init() error
+}
+
+// 服务关闭，单例在 Shutdown 时调用，请求作用域的服务在映射函数执行后调用
+type ServiceCloser interface {
+	Close() error
+}
+
+// 请求作用域服务的上下文键
+const contextRequestServicesKey string = "goboot.requestServices"
+
+// 不依赖请求的内置类型，单例可以注入
+var applicationArgTypes = map[reflect.Type]bool{
+	reflect.TypeOf((*GobootApplication)(nil)): true,
+	reflect.TypeOf((*gin.Engine)(nil)):        true,
+	reflect.TypeOf((*RedisCli)(nil)):          true,
+	reflect.TypeOf((*goredis.Client)(nil)):    true,
+	reflect.TypeOf((*gorm.DB)(nil)):           true,
+	reflect.TypeOf((*sql.DB)(nil)):            true,
+}
+
+// 注册的服务
+type serviceProvider struct {
+	Type  reflect.Type
+	Scope ServiceScope
+
+	ctor  reflect.Value // 构造函数，使用 Register 注册时无效
+	value reflect.Value // 单例的实例
+	ready bool          // 单例是否已经创建并注入字段
+}
+
+// 服务容器
+type serviceContainer struct {
+	providers map[reflect.Type]*serviceProvider
+	// 注册顺序
+	order []*serviceProvider
+	// 单例的创建顺序，用于 Init 和 Close
+	instances []*serviceProvider
+	// 已经初始化的单例数量，Close 只关闭已经初始化的单例
+	started int
+	built   bool
+}
+
+// 请求内的服务
+type requestServices struct {
+	values map[reflect.Type]reflect.Value
+	order  []reflect.Value
+}
+
+// 获取服务容器
+func (app *GobootApplication) serviceContainer() *serviceContainer {
+	if app.container == nil {
+		app.container = &serviceContainer{
+			providers: map[reflect.Type]*serviceProvider{},
+		}
+	}
+	return app.container
+}
+
+// 容器中是否有这个类型的服务
+func (boot *GobootApplication) hasService(arg reflect.Type) bool {
+	if boot.container == nil {
+		return false
+	}
+	_, ok := boot.container.providers[arg]
+	return ok
+}
+
+// 注册单例的构造函数
+func (app *GobootApplication) Provide(constructor interface{}) *GobootApplication {
+	return app.provide(constructor, ServiceScopeSingleton)
+}
+
+// 注册请求作用域的构造函数，同一个请求内只创建一次
+func (app *GobootApplication) ProvideRequest(constructor interface{}) *GobootApplication {
+	return app.provide(constructor, ServiceScopeRequest)
+}
+
+func (app *GobootApplication) provide(constructor interface{}, scope ServiceScope) *GobootApplication {
+	ctor := reflect.ValueOf(constructor)
+	if ctor.Kind() != reflect.Func || ctor.IsNil() {
+		app.startupErrors = append(app.startupErrors, fmt.Sprintf("goboot provide: constructor require function, but got %T", constructor))
+		return app
+	}
+	ctorType := ctor.Type()
+	if ctorType.IsVariadic() || ctorType.NumOut() < 1 || ctorType.NumOut() > 2 ||
+		(ctorType.NumOut() == 2 && ctorType.Out(1) != errorType) {
+		app.startupErrors = append(app.startupErrors, fmt.Sprintf("goboot provide: constructor %v require format func(deps...) T or func(deps...) (T, error)", ctorType))
+		return app
+	}
+	return app.addServiceProvider(&serviceProvider{
+		Type:  ctorType.Out(0),
+		Scope: scope,
+		ctor:  ctor,
+	})
+}
+
+// 注册单例的实例，按照实例的类型注册
+func (app *GobootApplication) Register(instance interface{}) *GobootApplication {
+	if instance == nil {
+		app.startupErrors = append(app.startupErrors, "goboot register: instance is nil")
+		return app
+	}
+	return app.addServiceProvider(&serviceProvider{
+		Type:  reflect.TypeOf(instance),
+		Scope: ServiceScopeSingleton,
+		value: reflect.ValueOf(instance),
+	})
+}
+
+func (app *GobootApplication) addServiceProvider(provider *serviceProvider) *GobootApplication {
+	container := app.serviceContainer()
+	if container.built {
+		app.startupErrors = append(app.startupErrors, fmt.Sprintf("goboot service %v registered after run", provider.Type))
+		return app
+	}
+	if _, ok := container.providers[provider.Type]; ok {
+		app.startupErrors = append(app.startupErrors, fmt.Sprintf("goboot service %v duplicate registered", provider.Type))
+		return app
+	}
+	container.providers[provider.Type] = provider
+	container.order = append(container.order, provider)
+	LogInfo("goboot register %v service %v", provider.Scope, provider.Type)
+	return app
+}
+
+// 获取单例服务，target 为服务类型的指针
+func (boot *GobootApplication) Resolve(target interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return fmt.Errorf("resolve target require pointer, but got %T", target)
+	}
+	val, err := boot.resolveDependency(nil, ptr.Elem().Type(), nil)
+	if err != nil {
+		return err
+	}
+	ptr.Elem().Set(val)
+	return nil
+}
+
+// 获取类型T的单例服务
+func ResolveService[T any](boot *GobootApplication) (T, error) {
+	var ret T
+	err := boot.Resolve(&ret)
+	return ret, err
+}
+
+// 解析依赖，c 为nil时只能解析不依赖请求的类型
+func (boot *GobootApplication) resolveDependency(c *gin.Context, arg reflect.Type, stack []reflect.Type) (reflect.Value, error) {
+	if boot.container != nil {
+		if provider, ok := boot.container.providers[arg]; ok {
+			if provider.Scope == ServiceScopeRequest {
+				if c == nil {
+					return reflect.Zero(arg), fmt.Errorf("request scoped service %v can only be injected in request", arg)
+				}
+				return boot.resolveRequestService(c, provider, stack)
+			}
+			return boot.resolveSingleton(provider, stack)
+		}
+	}
+	if bean, ok := boot.ConfigBeans[arg]; ok {
+		return reflect.ValueOf(bean), nil
+	}
+	if bean, ok := boot.ConfigBeans[reflect.PtrTo(arg)]; ok {
+		return reflect.ValueOf(bean).Elem(), nil
+	}
+	if c == nil {
+		if applicationArgTypes[arg] {
+			return boot.injectMappingArg(nil, arg, defaultArgInjectors[arg])
+		}
+	} else if injector, ok := boot.argInjector(arg); ok {
+		return boot.injectMappingArg(c, arg, injector)
+	}
+	return reflect.Zero(arg), fmt.Errorf("not found service of type %v", arg)
+}
+
+// 检查循环依赖
+func checkServiceCycle(arg reflect.Type, stack []reflect.Type) error {
+	for idx, item := range stack {
+		if item != arg {
+			continue
+		}
+		names := []string{}
+		for _, dep := range stack[idx:] {
+			names = append(names, dep.String())
+		}
+		names = append(names, arg.String())
+		return fmt.Errorf("circular dependency: %v", strings.Join(names, " -> "))
+	}
+	return nil
+}
+
+// 获取单例，没有创建时创建并注入字段
+func (boot *GobootApplication) resolveSingleton(provider *serviceProvider, stack []reflect.Type) (reflect.Value, error) {
+	if provider.ready {
+		return provider.value, nil
+	}
+	err := checkServiceCycle(provider.Type, stack)
+	if err != nil {
+		return reflect.Zero(provider.Type), err
+	}
+	stack = append(stack, provider.Type)
+	val := provider.value
+	if provider.ctor.IsValid() {
+		val, err = boot.constructService(nil, provider, stack)
+		if err != nil {
+			return reflect.Zero(provider.Type), err
+		}
+	}
+	err = boot.injectFields(nil, val, stack)
+	if err != nil {
+		return reflect.Zero(provider.Type), err
+	}
+	provider.value = val
+	provider.ready = true
+	boot.container.instances = append(boot.container.instances, provider)
+	return val, nil
+}
+
+// 调用构造函数创建服务
+func (boot *GobootApplication) constructService(c *gin.Context, provider *serviceProvider, stack []reflect.Type) (reflect.Value, error) {
+	ctorType := provider.ctor.Type()
+	args := make([]reflect.Value, 0, ctorType.NumIn())
+	for p := 0; p < ctorType.NumIn(); p++ {
+		val, err := boot.resolveDependency(c, ctorType.In(p), stack)
+		if err != nil {
+			return reflect.Zero(provider.Type), fmt.Errorf("service %v: %w", provider.Type, err)
+		}
+		args = append(args, val)
+	}
+	results := provider.ctor.Call(args)
+	if len(results) == 2 && !results[1].IsNil() {
+		return reflect.Zero(provider.Type), fmt.Errorf("service %v constructor failure: %w", provider.Type, results[1].Interface().(error))
+	}
+	return results[0], nil
+}
+
+// 注入结构体指针中使用 inject 标签的字段
+func (boot *GobootApplication) injectFields(c *gin.Context, target reflect.Value, stack []reflect.Type) error {
+	if target.Kind() != reflect.Ptr || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	elem := target.Elem()
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Type().Field(i)
+		if _, ok := field.Tag.Lookup("inject"); !ok {
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("inject field %v.%v must be exported", elem.Type().Name(), field.Name)
+		}
+		val, err := boot.resolveDependency(c, field.Type, stack)
+		if err != nil {
+			return fmt.Errorf("inject field %v.%v: %w", elem.Type().Name(), field.Name, err)
+		}
+		elem.Field(i).Set(val)
+	}
+	return nil
+}
+
+// 创建全部单例，并注入处理器和控制器的字段
+// 在 Run 时调用，错误记录为启动错误
+func (boot *GobootApplication) buildServices() {
+	container := boot.serviceContainer()
+	if container.built {
+		return
+	}
+	container.built = true
+	for _, provider := range container.order {
+		if provider.Scope != ServiceScopeSingleton {
+			continue
+		}
+		_, err := boot.resolveSingleton(provider, nil)
+		if err != nil {
+			boot.startupErrors = append(boot.startupErrors, fmt.Sprintf("goboot service: %v", err))
+		}
+	}
+	targets := append([]interface{}{}, boot.Handlers...)
+	for _, item := range boot.Controllers {
+		targets = append(targets, item)
+	}
+	for _, item := range targets {
+		err := boot.injectFields(nil, reflect.ValueOf(item), nil)
+		if err != nil {
+			boot.startupErrors = append(boot.startupErrors, fmt.Sprintf("goboot inject: %v", err))
+		}
+	}
+}
+
+// 按照创建顺序初始化单例，失败时关闭已经初始化的单例
+func (boot *GobootApplication) initServices() error {
+	container := boot.serviceContainer()
+	for _, provider := range container.instances[container.started:] {
+		if item, ok := provider.value.Interface().(ServiceInitializer); ok && !isNilValue(provider.value) {
+			LogInfo("goboot init service %v", provider.Type)
+			err := item.Init()
+			if err != nil {
+				initErr := fmt.Errorf("goboot init service %v failure: %w", provider.Type, err)
+				closeErr := boot.closeServices()
+				if closeErr != nil {
+					LogError("%v", closeErr)
+				}
+				return initErr
+			}
+		}
+		container.started++
+	}
+	return nil
+}
+
+// 按照创建的逆序关闭已经初始化的单例
+func (boot *GobootApplication) closeServices() error {
+	if boot.container == nil {
+		return nil
+	}
+	container := boot.container
+	var errs []string
+	for idx := container.started - 1; idx >= 0; idx-- {
+		provider := container.instances[idx]
+		if item, ok := provider.value.Interface().(ServiceCloser); ok && !isNilValue(provider.value) {
+			LogInfo("goboot close service %v", provider.Type)
+			err := item.Close()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%v: %v", provider.Type, err))
+			}
+		}
+	}
+	container.started = 0
+	if len(errs) > 0 {
+		return fmt.Errorf("goboot close service error: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// 获取请求作用域的服务，同一个请求内只创建一次
+func (boot *GobootApplication) resolveRequestService(c *gin.Context, provider *serviceProvider, stack []reflect.Type) (reflect.Value, error) {
+	var services *requestServices
+	if val, ok := c.Get(contextRequestServicesKey); ok {
+		services = val.(*requestServices)
+	} else {
+		services = &requestServices{values: map[reflect.Type]reflect.Value{}}
+		c.Set(contextRequestServicesKey, services)
+	}
+	if val, ok := services.values[provider.Type]; ok {
+		return val, nil
+	}
+	err := checkServiceCycle(provider.Type, stack)
+	if err != nil {
+		return reflect.Zero(provider.Type), err
+	}
+	stack = append(stack, provider.Type)
+	val, err := boot.constructService(c, provider, stack)
+	if err != nil {
+		return reflect.Zero(provider.Type), err
+	}
+	err = boot.injectFields(c, val, stack)
+	if err != nil {
+		return reflect.Zero(provider.Type), err
+	}
+	if item, ok := val.Interface().(ServiceInitializer); ok && !isNilValue(val) {
+		err = item.Init()
+		if err != nil {
+			return reflect.Zero(provider.Type), fmt.Errorf("init service %v failure: %w", provider.Type, err)
+		}
+	}
+	services.values[provider.Type] = val
+	services.order = append(services.order, val)
+	return val, nil
+}
+
+// 按照创建的逆序关闭请求作用域的服务
+func (boot *GobootApplication) closeRequestServices(c *gin.Context) {
+	val, ok := c.Get(contextRequestServicesKey)
+	if !ok {
+		return
+	}
+	services := val.(*requestServices)
+	for idx := len(services.order) - 1; idx >= 0; idx-- {
+		item := services.order[idx]
+		if closer, ok := item.Interface().(ServiceCloser); ok && !isNilValue(item) {
+			err := closer.Close()
+			if err != nil {
+				LogError("goboot close request service %v error: %v, request id: %v", item.Type(), err, GetRequestId(c))
+			}
+		}
+	}
+	services.values = map[reflect.Type]reflect.Value{}
+	services.order = nil
+}
@@ -0,0 +1,159 @@
+package goboot
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type containerTestEvents struct {
+	items []string
+}
+
+func (events *containerTestEvents) add(format string, args ...interface{}) {
+	events.items = append(events.items, fmt.Sprintf(format, args...))
+}
+
+type containerTestRepo struct {
+	events *containerTestEvents
+}
+
+func (repo *containerTestRepo) Init() error  { repo.events.add("init repo"); return nil }
+func (repo *containerTestRepo) Close() error { repo.events.add("close repo"); return nil }
+
+type containerTestService struct {
+	Repo   *containerTestRepo `inject:""`
+	events *containerTestEvents
+	failed bool
+}
+
+func (service *containerTestService) Init() error {
+	service.events.add("init service")
+	if service.failed {
+		return errors.New("init failed")
+	}
+	return nil
+}
+
+func (service *containerTestService) Close() error {
+	service.events.add("close service")
+	return nil
+}
+
+type containerTestScoped struct {
+	events *containerTestEvents
+	id     int
+}
+
+func (scoped *containerTestScoped) Close() error {
+	scoped.events.add("close scoped %v", scoped.id)
+	return nil
+}
+
+type containerTestApi struct {
+	Service *containerTestService `inject:""`
+}
+
+func (api *containerTestApi) Scoped(first *containerTestScoped, second *containerTestScoped) string {
+	return fmt.Sprintf("%v %v %v", first.id, first == second, api.Service.Repo != nil)
+}
+
+func newContainerTestApp(t *testing.T, events *containerTestEvents, failed bool) *GobootApplication {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.Mapping.Enable = true
+	config.Goboot.Server.Mapping.Items = []string{"/api/"}
+	boot := GetConfigApplication(config, nil)
+	boot.Provide(func(repo *containerTestRepo) *containerTestService {
+		return &containerTestService{events: events, failed: failed}
+	})
+	boot.Register(&containerTestRepo{events: events})
+	return boot
+}
+
+func TestServiceContainer(t *testing.T) {
+	events := &containerTestEvents{}
+	boot := newContainerTestApp(t, events, false)
+	created := 0
+	boot.ProvideRequest(func() *containerTestScoped {
+		created++
+		return &containerTestScoped{events: events, id: created}
+	})
+	boot.AddHandlers(&containerTestApi{})
+	boot.buildServices()
+	boot.compileMappings()
+	if len(boot.startupErrors) > 0 {
+		t.Fatalf("startup errors: %v", boot.startupErrors)
+	}
+	if err := boot.initServices(); err != nil {
+		t.Fatalf("init error: %v", err)
+	}
+
+	for _, want := range []string{"1 true true", "2 true true"} {
+		rec := serveMappingTest(boot, "GET", "/api/scoped")
+		if rec.Body.String() != want {
+			t.Fatalf("body = %v, want %v", rec.Body.String(), want)
+		}
+	}
+	service, err := ResolveService[*containerTestService](boot)
+	if err != nil || service.Repo == nil {
+		t.Fatalf("resolve service = %v, %v", service, err)
+	}
+	if err := boot.closeServices(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+	want := []string{"init repo", "init service", "close scoped 1", "close scoped 2", "close service", "close repo"}
+	if !reflect.DeepEqual(events.items, want) {
+		t.Fatalf("events = %v, want %v", events.items, want)
+	}
+}
+
+func TestServiceInitFailure(t *testing.T) {
+	events := &containerTestEvents{}
+	boot := newContainerTestApp(t, events, true)
+	boot.buildServices()
+	if err := boot.initServices(); err == nil || !strings.Contains(err.Error(), "init failed") {
+		t.Fatalf("init error = %v", err)
+	}
+	want := []string{"init repo", "init service", "close repo"}
+	if !reflect.DeepEqual(events.items, want) {
+		t.Fatalf("events = %v, want %v", events.items, want)
+	}
+}
+
+type containerTestA struct{}
+type containerTestB struct{}
+
+func TestServiceContainerErrors(t *testing.T) {
+	resetTestLogging(t)
+	boot := GetConfigApplication(&GobootConfig{}, nil)
+	boot.Provide(func(b *containerTestB) *containerTestA { return &containerTestA{} })
+	boot.Provide(func(a *containerTestA) *containerTestB { return &containerTestB{} })
+	boot.Provide(func(scoped *containerTestScoped) *containerTestEvents { return nil })
+	boot.ProvideRequest(func() *containerTestScoped { return nil })
+	boot.Provide("not a function")
+	boot.Provide(func() (*containerTestRepo, string) { return nil, "" })
+	boot.Register(&containerTestA{})
+	boot.buildServices()
+
+	wants := []string{
+		"constructor require function",
+		"require format func(deps...) T or func(deps...) (T, error)",
+		"*goboot.containerTestA duplicate registered",
+		"circular dependency: *goboot.containerTestA -> *goboot.containerTestB -> *goboot.containerTestA",
+		"request scoped service *goboot.containerTestScoped can only be injected in request",
+	}
+	errs := strings.Join(boot.startupErrors, "\n")
+	for _, want := range wants {
+		if !strings.Contains(errs, want) {
+			t.Errorf("startup errors %v not contains %v", errs, want)
+		}
+	}
+	boot.Register(&containerTestRepo{})
+	if !strings.Contains(boot.startupErrors[len(boot.startupErrors)-1], "registered after run") {
+		t.Errorf("expected error registering after run")
+	}
+}
@@ -0,0 +1,208 @@
+package goboot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 异常处理区
+// 映射函数返回的错误和panic，以及gin路由中的panic和 c.Error 添加的错误，都交给错误处理器链处理
+// 按照添加顺序调用 boot.ErrorHandlers，返回true表示已经处理，都未处理时使用 DefaultErrorHandler
+// 没有匹配的路由时为404，路径存在但请求方式不匹配时为405，并设置 Allow 响应头，同样经过错误处理器链
+//
+// 例如：
+// app.AddErrorHandlers(goboot.ErrorStatusHandler(gorm.ErrRecordNotFound, 404))
+// /////////////////////////////////////////////////////////
+
+// 错误处理器，返回true表示已经处理，不再调用后续的处理器
+type ErrorHandler func(c *gin.Context, err error) bool
+
+// 带有HTTP状态码的错误
+// 映射函数返回这个错误时，使用错误中的状态码响应
+type StatusError struct {
+	Status int
+	Msg    string
+	Err    error
+}
+
+func (err *StatusError) Error() string {
+	if err.Msg == "" && err.Err != nil {
+		return err.Err.Error()
+	}
+	return err.Msg
+}
+
+func (err *StatusError) Unwrap() error {
+	return err.Err
+}
+
+func (err *StatusError) StatusCode() int {
+	return err.Status
+}
+
+// 创建带有HTTP状态码的错误
+func NewStatusError(status int, msg string) *StatusError {
+	return &StatusError{
+		Status: status,
+		Msg:    msg,
+	}
+}
+
+// 使用HTTP状态码包装错误
+func WrapStatusError(status int, err error) *StatusError {
+	return &StatusError{
+		Status: status,
+		Err:    err,
+	}
+}
+
+// 处理请求时发生的panic
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// 创建panic错误，记录当前的调用栈
+func NewPanicError(value interface{}) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+	}
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprint(err.Value)
+}
+
+// panic的值是错误时，可以使用 errors.Is/As 判断
+func (err *PanicError) Unwrap() error {
+	if val, ok := err.Value.(error); ok {
+		return val
+	}
+	return nil
+}
+
+// 获取错误对应的HTTP状态码
+// 错误链中实现了 StatusCode() int 时使用这个状态码，否则为500
+func ErrorStatusCode(err error) int {
+	var coder interface{ StatusCode() int }
+	if errors.As(err, &coder) && coder.StatusCode() > 0 {
+		return coder.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// 添加错误处理器
+func (app *GobootApplication) AddErrorHandlers(handlers ...ErrorHandler) *GobootApplication {
+	app.ErrorHandlers = append(app.ErrorHandlers, handlers...)
+	return app
+}
+
+// 将指定的错误映射为HTTP状态码的错误处理器，使用 errors.Is 判断
+func ErrorStatusHandler(target error, status int) ErrorHandler {
+	return func(c *gin.Context, err error) bool {
+		if !errors.Is(err, target) {
+			return false
+		}
+		c.JSON(status, ApiError(status, err.Error()).WithRequestId(c))
+		return true
+	}
+}
+
+// 默认的错误处理器
+// 响应 ApiError，状态码使用 ErrorStatusCode，请求参数错误时data中为字段错误
+// 5xx的错误打印日志，panic同时打印调用栈，并且不响应panic的内容
+func DefaultErrorHandler(c *gin.Context, err error) bool {
+	status := ErrorStatusCode(err)
+	msg := err.Error()
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		if status >= http.StatusInternalServerError {
+			LogError("goboot panic recovered: %v, request: %v %v, request id: %v\n%s", panicErr.Value, c.Request.Method, c.Request.URL.Path, GetRequestId(c), panicErr.Stack)
+			msg = http.StatusText(status)
+		}
+	} else if status >= http.StatusInternalServerError {
+		LogError("goboot request error: %v, request: %v %v, request id: %v", err, c.Request.Method, c.Request.URL.Path, GetRequestId(c))
+	}
+	resp := ApiError(status, msg)
+	// 请求参数错误时，data中为每个字段的错误
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		resp.Data = bindErr.Fields
+	}
+	c.JSON(status, resp.WithRequestId(c))
+	return true
+}
+
+// 使用错误处理器链处理错误
+// 已经写入响应时只打印日志
+func (boot *GobootApplication) HandleError(c *gin.Context, err error) {
+	if c.Writer.Written() {
+		LogError("goboot request error after response written: %v, request: %v %v, request id: %v", err, c.Request.Method, c.Request.URL.Path, GetRequestId(c))
+		return
+	}
+	for _, handler := range boot.ErrorHandlers {
+		if handler(c, err) {
+			return
+		}
+	}
+	DefaultErrorHandler(c, err)
+}
+
+// 异常处理中间件
+// 将panic和 c.Error 添加的错误交给错误处理器链
+func (boot *GobootApplication) errorMiddleware(c *gin.Context) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+		// 由 net/http 处理的中断请求
+		if rec == http.ErrAbortHandler {
+			panic(rec)
+		}
+		boot.HandleError(c, NewPanicError(rec))
+		c.Abort()
+	}()
+	c.Next()
+	if len(c.Errors) > 0 && !c.Writer.Written() {
+		boot.HandleError(c, c.Errors.Last().Err)
+	}
+}
+
+// 没有匹配路由时的处理
+func (boot *GobootApplication) notFoundHandler(c *gin.Context) {
+	if c.Writer.Written() {
+		return
+	}
+	boot.HandleError(c, NewStatusError(http.StatusNotFound, "request not found."))
+}
+
+// 请求方式不匹配时的处理，gin已经设置了 Allow 响应头
+func (boot *GobootApplication) methodNotAllowedHandler(c *gin.Context) {
+	if c.Writer.Written() {
+		return
+	}
+	boot.HandleError(c, NewStatusError(http.StatusMethodNotAllowed, "request method not allowed, allow: "+c.Writer.Header().Get("Allow")))
+}
+
+// 存在路由时开启gin的405响应
+// gin 在没有任何路由时开启，请求会因为 Allow 列表的容量为负数而panic
+func (boot *GobootApplication) enableMethodNotAllowed() {
+	if len(boot.App.Routes()) > 0 {
+		boot.App.HandleMethodNotAllowed = true
+	}
+}
+
+// 响应405，设置 Allow 响应头
+func (boot *GobootApplication) methodNotAllowed(c *gin.Context, allows []string) {
+	c.Header("Allow", strings.Join(allows, ", "))
+	c.Status(http.StatusMethodNotAllowed)
+	boot.methodNotAllowedHandler(c)
+}
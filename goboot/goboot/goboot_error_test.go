@@ -0,0 +1,88 @@
+package goboot
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errTestNotFound = errors.New("record not found")
+
+func TestErrorStatusCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("plain"), 500},
+		{NewStatusError(404, "missing"), 404},
+		{fmt.Errorf("wrap: %w", NewStatusError(409, "conflict")), 409},
+		{WrapStatusError(400, errTestNotFound), 400},
+		{NewPanicError(NewStatusError(403, "forbidden")), 403},
+	}
+	for _, item := range cases {
+		if got := ErrorStatusCode(item.err); got != item.want {
+			t.Errorf("ErrorStatusCode(%v) = %v, want %v", item.err, got, item.want)
+		}
+	}
+	if !errors.Is(WrapStatusError(400, errTestNotFound), errTestNotFound) {
+		t.Errorf("wrapped error should match with errors.Is")
+	}
+}
+
+func TestErrorHandlerChain(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	boot := GetConfigApplication(config, nil)
+	boot.AddErrorHandlers(ErrorStatusHandler(errTestNotFound, 404))
+	boot.App.GET("/found", func(c *gin.Context) {
+		c.Error(fmt.Errorf("query: %w", errTestNotFound))
+	})
+	boot.App.GET("/panic", func(c *gin.Context) {
+		panic("secret detail")
+	})
+	boot.App.POST("/only-post", func(c *gin.Context) {})
+	boot.enableMethodNotAllowed()
+
+	cases := []struct {
+		method string
+		target string
+		code   int
+		body   string
+	}{
+		{"GET", "/found", 404, "record not found"},
+		{"GET", "/panic", 500, "Internal Server Error"},
+		{"GET", "/missing", 404, "request not found."},
+		{"GET", "/only-post", 405, "allow: POST"},
+	}
+	for _, item := range cases {
+		rec := httptest.NewRecorder()
+		boot.App.ServeHTTP(rec, httptest.NewRequest(item.method, item.target, nil))
+		if rec.Code != item.code || !strings.Contains(rec.Body.String(), item.body) {
+			t.Errorf("%v %v = %v %v, want %v %v", item.method, item.target, rec.Code, rec.Body.String(), item.code, item.body)
+		}
+		if strings.Contains(rec.Body.String(), "secret detail") {
+			t.Errorf("panic value should not be responded: %v", rec.Body.String())
+		}
+	}
+}
+
+func TestMethodNotAllowedWithoutRoutes(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	boot := GetConfigApplication(config, nil)
+	boot.enableMethodNotAllowed()
+	if boot.App.HandleMethodNotAllowed {
+		t.Fatalf("405 should not be enabled without routes")
+	}
+	rec := httptest.NewRecorder()
+	boot.App.ServeHTTP(rec, httptest.NewRequest("GET", "/x", nil))
+	if rec.Code != 404 {
+		t.Fatalf("GET /x = %v %v, want 404", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,175 @@
+package goboot
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot HTTPS区
+// /////////////////////////////////////////////////////////
+
+// TLS版本名称映射
+var tlsVersionNames = map[string]uint16{
+	"TLS1.0": tls.VersionTLS10,
+	"TLS1.1": tls.VersionTLS11,
+	"TLS1.2": tls.VersionTLS12,
+	"TLS1.3": tls.VersionTLS13,
+}
+
+// 根据HTTPS配置构建TLS配置
+// 开启selfSigned时，证书文件不存在会自动生成自签名证书
+func ResolveTlsConfig(https Https) (*tls.Config, error) {
+	if https.SelfSigned {
+		err := EnsureSelfSignedCert(https.PemPath, https.KeyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if https.MinVersion != "" {
+		version, ok := tlsVersionNames[strings.ToUpper(https.MinVersion)]
+		if !ok {
+			return nil, fmt.Errorf("un-support tls min version: %v", https.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if len(https.CipherSuites) > 0 {
+		suites := tlsCipherSuiteIds()
+		for _, name := range https.CipherSuites {
+			id, ok := suites[name]
+			if !ok {
+				return nil, fmt.Errorf("un-support tls cipher suite: %v", name)
+			}
+			config.CipherSuites = append(config.CipherSuites, id)
+		}
+	}
+
+	return config, nil
+}
+
+// 获取加密套件名称到ID的映射
+func tlsCipherSuiteIds() map[string]uint16 {
+	suites := map[string]uint16{}
+	for _, item := range tls.CipherSuites() {
+		suites[item.Name] = item.ID
+	}
+	for _, item := range tls.InsecureCipherSuites() {
+		suites[item.Name] = item.ID
+	}
+	return suites
+}
+
+// http 重定向到 https 的处理器
+func HttpsRedirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, fmt.Sprintf("%v", httpsPort))
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
+
+// 当pem或key文件不存在时，生成自签名证书
+// 证书适用于 localhost/127.0.0.1/::1 以及本机网卡地址，有效期一年
+func EnsureSelfSignedCert(pemPath string, keyPath string) error {
+	_, pemErr := os.Stat(pemPath)
+	_, keyErr := os.Stat(keyPath)
+	if pemErr == nil && keyErr == nil {
+		return nil
+	}
+
+	LogWarn("goboot https generate self-signed certificate, pem: %v, key: %v, only for development!", pemPath, keyPath)
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("generate private key error: %w", err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("generate serial number error: %w", err)
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"goboot self-signed"},
+			CommonName:   "localhost",
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if ok && !ipNet.IP.IsLoopback() {
+				template.IPAddresses = append(template.IPAddresses, ipNet.IP)
+			}
+		}
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return fmt.Errorf("create certificate error: %w", err)
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("marshal private key error: %w", err)
+	}
+
+	err = writePemFile(pemPath, "CERTIFICATE", derBytes, 0644)
+	if err != nil {
+		return err
+	}
+	return writePemFile(keyPath, "PRIVATE KEY", keyBytes, 0600)
+}
+
+// 写入PEM格式文件
+func writePemFile(filePath string, blockType string, bytes []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("create dir error: %w", err)
+	}
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("create file %s error: %w", filePath, err)
+	}
+	defer file.Close()
+	err = pem.Encode(file, &pem.Block{Type: blockType, Bytes: bytes})
+	if err != nil {
+		return fmt.Errorf("write file %s error: %w", filePath, err)
+	}
+	return nil
+}
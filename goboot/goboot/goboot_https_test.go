@@ -0,0 +1,79 @@
+package goboot
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveTlsConfig(t *testing.T) {
+	config, err := ResolveTlsConfig(Https{})
+	if err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	if config.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("default min version = %x, want TLS1.2", config.MinVersion)
+	}
+
+	config, err = ResolveTlsConfig(Https{
+		MinVersion:   "tls1.3",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	})
+	if err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	if config.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("min version = %x, want TLS1.3", config.MinVersion)
+	}
+	if len(config.CipherSuites) != 1 || config.CipherSuites[0] != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Fatalf("cipher suites = %v", config.CipherSuites)
+	}
+
+	if _, err := ResolveTlsConfig(Https{MinVersion: "SSL3"}); err == nil {
+		t.Fatalf("expected error for un-support min version")
+	}
+	if _, err := ResolveTlsConfig(Https{CipherSuites: []string{"NOPE"}}); err == nil {
+		t.Fatalf("expected error for un-support cipher suite")
+	}
+}
+
+func TestEnsureSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	pemPath := filepath.Join(dir, "certs", "server.pem")
+	keyPath := filepath.Join(dir, "certs", "server.key")
+
+	_, err := ResolveTlsConfig(Https{SelfSigned: true, PemPath: pemPath, KeyPath: keyPath})
+	if err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	cert, err := tls.LoadX509KeyPair(pemPath, keyPath)
+	if err != nil {
+		t.Fatalf("load generated cert error: %v", err)
+	}
+	if len(cert.Certificate) == 0 {
+		t.Fatalf("generated cert is empty")
+	}
+}
+
+func TestHttpsRedirectHandler(t *testing.T) {
+	cases := []struct {
+		port   int
+		target string
+		want   string
+	}{
+		{443, "http://example.com/a/b?x=1", "https://example.com/a/b?x=1"},
+		{8443, "http://example.com:8080/a", "https://example.com:8443/a"},
+	}
+	for _, item := range cases {
+		req := httptest.NewRequest("GET", item.target, nil)
+		rec := httptest.NewRecorder()
+		HttpsRedirectHandler(item.port).ServeHTTP(rec, req)
+		if rec.Code != 301 {
+			t.Fatalf("status = %v, want 301", rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != item.want {
+			t.Fatalf("location = %v, want %v", got, item.want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package goboot
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 拦截器区
+// 拦截器在映射函数的参数注入之后、调用之前执行，可以获取处理器、函数名和注入的参数
+// 参数绑定或校验失败时不执行拦截器，不区分路由的登录校验等仍然适合使用gin中间件
+// 按照添加顺序执行，Before 返回非nil的 *ApiResp 时，使用这个响应并且不再调用后续的拦截器和函数
+// Around 中调用 inv.Proceed() 执行后续的拦截器和函数，不调用时函数不执行，返回非nil的 *ApiResp 时替换响应
+// After 在后续的拦截器和函数执行后调用，可以获取返回值，按照添加的逆序执行，Before 短路的拦截器不调用
+// 需要HTTP状态码时，在拦截器中调用 c.Status 设置
+//
+// 作用范围 InterceptorScope 中为空的条件不限制，多个条件需要同时满足
+//
+//	app.AddInterceptors(&goboot.Interceptor{
+//		Scope: goboot.InterceptorScope{
+//			Prefixes: []string{"/api/"},
+//			Methods:  []string{"XP_*", "XD_*"},
+//		},
+//		Before: func(inv *goboot.Invocation) *goboot.ApiResp {
+//			if inv.Context.GetHeader("X-Token") == "" {
+//				return goboot.ApiError(401, "not login")
+//			}
+//			return nil
+//		},
+//	})
+//
+// /////////////////////////////////////////////////////////
+
+// 拦截器的作用范围
+type InterceptorScope struct {
+	Prefixes    []string      // 路由路径的前缀，例如mapping前缀 /api/ 或者controller路径
+	Handlers    []interface{} // 处理器或控制器的类型，使用实例或者nil指针，例如 (*UserController)(nil)
+	Methods     []string      // 函数名的匹配模式，使用 path.Match 匹配函数名或者去除请求方式前缀的函数名，例如 XP_*, User_*
+	HttpMethods []string      // 请求方式，例如 GET, POST
+}
+
+// 拦截器
+type Interceptor struct {
+	Scope  InterceptorScope
+	Before func(inv *Invocation) *ApiResp
+	Around func(inv *Invocation) *ApiResp
+	After  func(inv *Invocation)
+}
+
+// 映射函数的调用信息
+type Invocation struct {
+	Context     *gin.Context
+	Route       *MappingRoute
+	Handler     interface{}   // 处理器对象
+	HandlerType reflect.Type  // 处理器类型，去除指针
+	Method      string        // 函数名，例如 XP_User_Save
+	Args        []interface{} // 注入的参数，可以在函数调用前替换
+	Results     []interface{} // 函数的返回值，函数调用后有效
+	Resp        *ApiResp      // 拦截器短路时的响应
+
+	// 执行后续的拦截器和函数
+	next      func()
+	proceeded bool
+}
+
+// 执行后续的拦截器和函数，只会执行一次
+func (inv *Invocation) Proceed() {
+	if inv.proceeded || inv.next == nil {
+		return
+	}
+	inv.proceeded = true
+	inv.next()
+}
+
+// 函数是否已经执行
+func (inv *Invocation) Invoked() bool {
+	return inv.Results != nil
+}
+
+// 添加拦截器，需要在 Run 之前添加
+func (app *GobootApplication) AddInterceptors(interceptors ...*Interceptor) *GobootApplication {
+	for _, item := range interceptors {
+		if item == nil {
+			continue
+		}
+		for _, pattern := range item.Scope.Methods {
+			_, err := path.Match(pattern, "")
+			if err != nil {
+				app.startupErrors = append(app.startupErrors, fmt.Sprintf("goboot interceptor method pattern %v: %v", pattern, err))
+			}
+		}
+		app.Interceptors = append(app.Interceptors, item)
+	}
+	return app
+}
+
+// 拦截器是否作用于这个路由
+func (scope InterceptorScope) matchRoute(route *MappingRoute) bool {
+	if len(scope.Prefixes) > 0 {
+		matched := false
+		for _, item := range scope.Prefixes {
+			if strings.HasPrefix(route.Path, item) || route.Base == item {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(scope.Handlers) > 0 {
+		matched := false
+		handlerType := indirectType(reflect.TypeOf(route.Handler))
+		for _, item := range scope.Handlers {
+			if indirectType(reflect.TypeOf(item)) == handlerType {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(scope.Methods) > 0 {
+		matched := false
+		for _, item := range scope.Methods {
+			ok1, _ := path.Match(item, route.FuncName)
+			ok2, _ := path.Match(item, route.Name)
+			if ok1 || ok2 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// 拦截器是否作用于这个请求方式
+func (scope InterceptorScope) matchHttpMethod(method string) bool {
+	if len(scope.HttpMethods) == 0 {
+		return true
+	}
+	for _, item := range scope.HttpMethods {
+		if strings.EqualFold(item, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// 去除指针的类型
+func indirectType(rtype reflect.Type) reflect.Type {
+	for rtype != nil && rtype.Kind() == reflect.Ptr {
+		rtype = rtype.Elem()
+	}
+	return rtype
+}
+
+// 经过拦截器链调用映射函数，返回函数的返回值
+// 拦截器短路时返回nil，并已经写入响应，函数没有执行时也返回nil
+func (boot *GobootApplication) interceptMappingRoute(c *gin.Context, route *MappingRoute, callArgs []reflect.Value) []reflect.Value {
+	chain := []*Interceptor{}
+	for _, item := range boot.Interceptors {
+		if item.Scope.matchRoute(route) && item.Scope.matchHttpMethod(c.Request.Method) {
+			chain = append(chain, item)
+		}
+	}
+	if len(chain) == 0 {
+		return route.fn.Call(callArgs)
+	}
+
+	inv := &Invocation{
+		Context:     c,
+		Route:       route,
+		Handler:     route.Handler,
+		HandlerType: indirectType(reflect.TypeOf(route.Handler)),
+		Method:      route.FuncName,
+		Args:        make([]interface{}, len(callArgs)),
+	}
+	for idx, item := range callArgs {
+		inv.Args[idx] = item.Interface()
+	}
+
+	var results []reflect.Value
+	var run func(idx int)
+	run = func(idx int) {
+		if inv.Resp != nil {
+			return
+		}
+		if idx == len(chain) {
+			args := make([]reflect.Value, len(inv.Args))
+			for p, item := range inv.Args {
+				if item == nil {
+					args[p] = reflect.Zero(route.argTypes[p])
+				} else {
+					args[p] = reflect.ValueOf(item)
+				}
+			}
+			results = route.fn.Call(args)
+			inv.Results = make([]interface{}, len(results))
+			for p, item := range results {
+				inv.Results[p] = item.Interface()
+			}
+			return
+		}
+		item := chain[idx]
+		if item.Before != nil {
+			if resp := item.Before(inv); resp != nil {
+				inv.Resp = resp
+				return
+			}
+		}
+		if item.Around != nil {
+			parentNext, parentProceeded := inv.next, inv.proceeded
+			inv.next = func() {
+				run(idx + 1)
+			}
+			inv.proceeded = false
+			resp := item.Around(inv)
+			inv.next, inv.proceeded = parentNext, parentProceeded
+			if resp != nil && inv.Resp == nil {
+				inv.Resp = resp
+			}
+		} else {
+			run(idx + 1)
+		}
+		if item.After != nil {
+			item.After(inv)
+		}
+	}
+	run(0)
+
+	// 拦截器的响应优先于函数的返回值
+	if inv.Resp != nil {
+		if !c.Writer.Written() {
+			WriteMappingResult(c, inv.Resp, false)
+		}
+		return nil
+	}
+	return results
+}
@@ -0,0 +1,132 @@
+package goboot
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type interceptorTestApi struct {
+	calls []string
+}
+
+func (api *interceptorTestApi) Routes() map[string]string {
+	return map[string]string{"/hello/:name": "XG_Hello"}
+}
+
+func (api *interceptorTestApi) XG_Hello(name string) string {
+	api.calls = append(api.calls, "hello "+name)
+	return "hello " + name
+}
+
+func (api *interceptorTestApi) XP_Save() string {
+	api.calls = append(api.calls, "save")
+	return "saved"
+}
+
+type interceptorTestOther struct{}
+
+func TestInterceptorChain(t *testing.T) {
+	api := &interceptorTestApi{}
+	boot := newMappingTestApp(t, api)
+	events := []string{}
+	boot.AddInterceptors(&Interceptor{
+		Before: func(inv *Invocation) *ApiResp {
+			events = append(events, "before1 "+inv.Method)
+			return nil
+		},
+		After: func(inv *Invocation) {
+			events = append(events, "after1")
+		},
+	}, &Interceptor{
+		Scope: InterceptorScope{Methods: []string{"XG_*"}},
+		Around: func(inv *Invocation) *ApiResp {
+			events = append(events, "around2")
+			inv.Args[0] = "jerry"
+			inv.Proceed()
+			inv.Proceed()
+			events = append(events, "around2 done "+inv.Results[0].(string))
+			return nil
+		},
+		After: func(inv *Invocation) {
+			events = append(events, "after2")
+		},
+	}, &Interceptor{
+		Scope: InterceptorScope{HttpMethods: []string{"post"}},
+		Before: func(inv *Invocation) *ApiResp {
+			events = append(events, "before3")
+			return ApiError(403, "denied")
+		},
+		After: func(inv *Invocation) {
+			events = append(events, "after3")
+		},
+	})
+
+	rec := serveMappingTest(boot, "GET", "/api/hello/tom")
+	if rec.Code != 200 || rec.Body.String() != "hello jerry" {
+		t.Fatalf("response = %v %v", rec.Code, rec.Body.String())
+	}
+	want := []string{"before1 XG_Hello", "around2", "around2 done hello jerry", "after2", "after1"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+
+	events = nil
+	rec = serveMappingTest(boot, "POST", "/api/save")
+	resp := ApiResp{}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Code != 403 || resp.Msg != "denied" {
+		t.Fatalf("short circuit response = %v %v", rec.Code, rec.Body.String())
+	}
+	want = []string{"before1 XP_Save", "before3", "after1"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	if !reflect.DeepEqual(api.calls, []string{"hello jerry"}) {
+		t.Fatalf("calls = %v", api.calls)
+	}
+}
+
+func TestInterceptorScopeMatch(t *testing.T) {
+	route := &MappingRoute{
+		Base:     "/api/",
+		Path:     "/api/user/save",
+		Name:     "User_Save",
+		FuncName: "XP_User_Save",
+		Handler:  &interceptorTestApi{},
+	}
+	cases := []struct {
+		scope InterceptorScope
+		want  bool
+	}{
+		{InterceptorScope{}, true},
+		{InterceptorScope{Prefixes: []string{"/api/user/"}}, true},
+		{InterceptorScope{Prefixes: []string{"/admin/"}}, false},
+		{InterceptorScope{Handlers: []interface{}{(*interceptorTestApi)(nil)}}, true},
+		{InterceptorScope{Handlers: []interface{}{interceptorTestOther{}}}, false},
+		{InterceptorScope{Methods: []string{"XP_*"}}, true},
+		{InterceptorScope{Methods: []string{"User_*"}}, true},
+		{InterceptorScope{Methods: []string{"XG_*"}}, false},
+		{InterceptorScope{Prefixes: []string{"/api/"}, Methods: []string{"XG_*"}}, false},
+	}
+	for idx, item := range cases {
+		if got := item.scope.matchRoute(route); got != item.want {
+			t.Errorf("case %v matchRoute = %v, want %v", idx, got, item.want)
+		}
+	}
+	scope := InterceptorScope{HttpMethods: []string{"get"}}
+	if !scope.matchHttpMethod("GET") || scope.matchHttpMethod("POST") {
+		t.Errorf("matchHttpMethod result error")
+	}
+}
+
+func TestAddInterceptorsInvalidPattern(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	boot := GetConfigApplication(config, nil)
+	boot.AddInterceptors(&Interceptor{Scope: InterceptorScope{Methods: []string{"XP_["}}}, nil)
+	if len(boot.startupErrors) != 1 || len(boot.Interceptors) != 1 {
+		t.Fatalf("startup errors = %v, interceptors = %v", boot.startupErrors, len(boot.Interceptors))
+	}
+}
@@ -0,0 +1,668 @@
+package goboot
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot JWT区
+// 开启 goboot.server.jwt 后，使用 JwtManager 签发和校验JWT，支持 HS256/384/512, RS256/384/512, ES256/384/512
+// 密钥在 keys 中配置，HMAC使用 secret，RSA/ECDSA使用PEM格式的密钥文件
+// 签发时使用 activeKey 指定的密钥，并在头部写入 kid，校验时按照 kid 选择密钥
+// 轮换密钥时添加新的密钥并修改 activeKey，旧的密钥保留到已签发的令牌过期，只校验的密钥可以只配置公钥
+//
+// 校验签名以及 exp, nbf, iss, aud，失败时响应401，作用于映射、控制器、代理和文件服务
+// 路径模式与令牌认证相同，includes 为空时全部路径需要认证，excludes 优先于 includes
+// 同时开启令牌认证时，JWT格式的令牌由JWT校验，其他令牌由令牌认证校验，参考令牌认证区
+// 映射函数可以注入 *goboot.JwtClaims，同时当前用户 *goboot.Principal 使用 sub, name, roles, data
+//
+// 刷新令牌的类型 typ 为 refresh，不能作为访问令牌，使用一次后失效，存储在内存或者redis中
+//
+//	func (api *Api) XP_Login(boot *goboot.GobootApplication, req *LoginReq) (*goboot.JwtTokenPair, error) {
+//		user, err := api.check(req)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return boot.JwtManager.IssuePair(&goboot.JwtClaims{Subject: user.Id, Name: user.Name, Roles: user.Roles})
+//	}
+//
+//	func (api *Api) XP_Refresh(boot *goboot.GobootApplication, req *RefreshReq) (*goboot.JwtTokenPair, error) {
+//		return boot.JwtManager.Refresh(req.RefreshToken)
+//	}
+//
+// /////////////////////////////////////////////////////////
+
+// 默认的访问令牌有效时间，单位秒
+const DefaultJwtExpire int = 7200
+
+// 默认的刷新令牌有效时间，单位秒
+const DefaultJwtRefreshExpire int = 7 * 24 * 3600
+
+// 刷新令牌的类型
+const JwtTypeRefresh string = "refresh"
+
+// JWT声明的上下文键
+const ContextJwtClaimsKey string = "goboot.jwtClaims"
+
+// 已使用的刷新令牌存储在redis中的键前缀
+const jwtRedisKeyPrefix string = "goboot:jwt:revoked:"
+
+// 签名算法使用的摘要
+var jwtAlgHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// ECDSA算法对应的曲线
+var jwtAlgCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+// JWT配置
+type Jwt struct {
+	Enable        bool     `yaml:"enable"`
+	TokenName     string   `yaml:"tokenName"`     // 令牌名称，默认 Authorization，请求头中可以带 Bearer 前缀
+	Issuer        string   `yaml:"issuer"`        // 签发者，配置时校验 iss
+	Audience      []string `yaml:"audience"`      // 接收者，配置时校验 aud 包含其中一个
+	Expire        int      `yaml:"expire"`        // 访问令牌有效时间，单位秒，默认7200
+	RefreshExpire int      `yaml:"refreshExpire"` // 刷新令牌有效时间，单位秒，默认7天
+	RefreshStore  string   `yaml:"refreshStore"`  // 已使用的刷新令牌存储，memory/redis，默认memory
+	Leeway        int      `yaml:"leeway"`        // 校验 exp, nbf 时允许的时钟误差，单位秒
+	ActiveKey     string   `yaml:"activeKey"`     // 签发使用的密钥kid，默认第一个密钥
+	Keys          []JwtKey `yaml:"keys"`
+	Includes      []string `yaml:"includes"` // 需要认证的路径模式，为空时全部路径需要认证
+	Excludes      []string `yaml:"excludes"` // 不需要认证的路径模式，例如登录和刷新接口
+}
+
+// JWT密钥配置
+type JwtKey struct {
+	Kid            string `yaml:"kid"`
+	Alg            string `yaml:"alg"`            // 签名算法，例如 HS256, RS256, ES256
+	Secret         string `yaml:"secret"`         // HMAC密钥
+	PrivateKeyPath string `yaml:"privateKeyPath"` // RSA/ECDSA私钥文件，PEM格式
+	PublicKeyPath  string `yaml:"publicKeyPath"`  // RSA/ECDSA公钥或证书文件，PEM格式，未配置时使用私钥中的公钥
+}
+
+// JWT的接收者，可以是字符串或者字符串数组
+type JwtAudience []string
+
+func (aud *JwtAudience) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*aud = JwtAudience{str}
+		return nil
+	}
+	var arr []string
+	if err := json.Unmarshal(data, &arr); err != nil {
+		return err
+	}
+	*aud = arr
+	return nil
+}
+
+// JWT声明
+type JwtClaims struct {
+	Id        string                 `json:"jti,omitempty"`
+	Issuer    string                 `json:"iss,omitempty"`
+	Subject   string                 `json:"sub,omitempty"`
+	Audience  JwtAudience            `json:"aud,omitempty"`
+	ExpiresAt int64                  `json:"exp,omitempty"`
+	NotBefore int64                  `json:"nbf,omitempty"`
+	IssuedAt  int64                  `json:"iat,omitempty"`
+	Type      string                 `json:"typ,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Roles     []string               `json:"roles,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// 转换为当前用户
+func (claims *JwtClaims) Principal() *Principal {
+	return &Principal{
+		Id:       claims.Subject,
+		Name:     claims.Name,
+		Roles:    claims.Roles,
+		Attrs:    claims.Data,
+		ExpireAt: time.Unix(claims.ExpiresAt, 0),
+	}
+}
+
+// 签发的访问令牌和刷新令牌
+type JwtTokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"` // 访问令牌有效时间，单位秒
+}
+
+// JWT头部
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// 加载后的密钥
+type jwtKey struct {
+	kid     string
+	alg     string
+	hash    crypto.Hash
+	secret  []byte
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// 是否可以用于签发
+func (key *jwtKey) canSign() bool {
+	return len(key.secret) > 0 || key.private != nil
+}
+
+// JWT签发和校验
+type JwtManager struct {
+	config Jwt
+	keys   map[string]*jwtKey
+	active *jwtKey
+	redis  *RedisCli
+
+	lock    sync.Mutex
+	revoked map[string]int64
+}
+
+// 获取JWT声明，未认证时返回nil
+func GetJwtClaims(c *gin.Context) *JwtClaims {
+	value, ok := c.Get(ContextJwtClaimsKey)
+	if !ok {
+		return nil
+	}
+	claims, _ := value.(*JwtClaims)
+	return claims
+}
+
+// 根据配置创建 JwtManager，加载全部密钥
+// 刷新令牌使用redis存储时需要传入 RedisCli
+func NewJwtManager(config Jwt, redis *RedisCli) (*JwtManager, error) {
+	if len(config.Keys) == 0 {
+		return nil, errors.New("jwt require at least one key")
+	}
+	if config.RefreshStore == "redis" && redis == nil {
+		return nil, errors.New("jwt redis refresh store require enable redis config [goboot.server.redis.enable]")
+	}
+	manager := &JwtManager{
+		config:  config,
+		keys:    map[string]*jwtKey{},
+		revoked: map[string]int64{},
+	}
+	if config.RefreshStore == "redis" {
+		manager.redis = redis
+	}
+	for i, item := range config.Keys {
+		key, err := loadJwtKey(item)
+		if err != nil {
+			return nil, fmt.Errorf("jwt keys.%v: %v", i, err)
+		}
+		if _, ok := manager.keys[key.kid]; ok {
+			return nil, fmt.Errorf("jwt keys.%v: duplicate kid %v", i, key.kid)
+		}
+		manager.keys[key.kid] = key
+		if manager.active == nil && (config.ActiveKey == "" || config.ActiveKey == key.kid) {
+			manager.active = key
+		}
+	}
+	if manager.active == nil {
+		return nil, fmt.Errorf("jwt activeKey %v not found in keys", config.ActiveKey)
+	}
+	if !manager.active.canSign() {
+		return nil, fmt.Errorf("jwt activeKey %v require secret or private key to sign", manager.active.kid)
+	}
+	return manager, nil
+}
+
+// 加载密钥
+func loadJwtKey(config JwtKey) (*jwtKey, error) {
+	hash, ok := jwtAlgHashes[config.Alg]
+	if !ok {
+		return nil, fmt.Errorf("un-support alg %v", config.Alg)
+	}
+	key := &jwtKey{
+		kid:  config.Kid,
+		alg:  config.Alg,
+		hash: hash,
+	}
+	if strings.HasPrefix(config.Alg, "HS") {
+		if config.Secret == "" {
+			return nil, fmt.Errorf("alg %v require secret", config.Alg)
+		}
+		key.secret = []byte(config.Secret)
+		return key, nil
+	}
+
+	if config.PrivateKeyPath != "" {
+		block, err := readPemBlock(config.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		private, err := parsePrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse private key %v: %v", config.PrivateKeyPath, err)
+		}
+		key.private = private
+		key.public = private.Public()
+	}
+	if config.PublicKeyPath != "" {
+		block, err := readPemBlock(config.PublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		public, err := parsePublicKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("parse public key %v: %v", config.PublicKeyPath, err)
+		}
+		key.public = public
+	}
+	if key.public == nil {
+		return nil, fmt.Errorf("alg %v require privateKeyPath or publicKeyPath", config.Alg)
+	}
+
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(config.Alg, "RS") {
+			return nil, fmt.Errorf("alg %v not match rsa key", config.Alg)
+		}
+	case *ecdsa.PublicKey:
+		if jwtAlgCurves[config.Alg] != public.Curve {
+			return nil, fmt.Errorf("alg %v not match ecdsa key of curve %v", config.Alg, public.Curve.Params().Name)
+		}
+	default:
+		return nil, fmt.Errorf("un-support key type %T", key.public)
+	}
+	return key, nil
+}
+
+// 读取PEM文件的第一个块
+func readPemBlock(filePath string) (*pem.Block, error) {
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(bytes)
+	if block == nil {
+		return nil, fmt.Errorf("%v is not pem file", filePath)
+	}
+	return block, nil
+}
+
+// 解析私钥，支持 PKCS8, PKCS1, SEC1
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("un-support key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("un-support private key format")
+}
+
+// 解析公钥，支持 PKIX, PKCS1 以及证书
+func parsePublicKey(block *pem.Block) (crypto.PublicKey, error) {
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("un-support public key format")
+}
+
+// 签名
+func (key *jwtKey) sign(content string) ([]byte, error) {
+	if len(key.secret) > 0 {
+		mac := hmac.New(key.hash.New, key.secret)
+		mac.Write([]byte(content))
+		return mac.Sum(nil), nil
+	}
+	if key.private == nil {
+		return nil, fmt.Errorf("jwt key %v has no private key", key.kid)
+	}
+	hasher := key.hash.New()
+	hasher.Write([]byte(content))
+	digest := hasher.Sum(nil)
+	switch private := key.private.(type) {
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, private, key.hash, digest)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, private, digest)
+		if err != nil {
+			return nil, err
+		}
+		// JWS 使用固定长度的 r||s
+		size := (private.Curve.Params().BitSize + 7) / 8
+		sig := make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		return sig, nil
+	}
+	return nil, fmt.Errorf("un-support key type %T", key.private)
+}
+
+// 校验签名
+func (key *jwtKey) verify(content string, sig []byte) bool {
+	if len(key.secret) > 0 {
+		mac := hmac.New(key.hash.New, key.secret)
+		mac.Write([]byte(content))
+		return hmac.Equal(sig, mac.Sum(nil))
+	}
+	hasher := key.hash.New()
+	hasher.Write([]byte(content))
+	digest := hasher.Sum(nil)
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(public, key.hash, digest, sig) == nil
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(public, digest, r, s)
+	}
+	return false
+}
+
+// 访问令牌有效时间
+func (manager *JwtManager) expire() time.Duration {
+	if manager.config.Expire <= 0 {
+		return time.Duration(DefaultJwtExpire) * time.Second
+	}
+	return time.Duration(manager.config.Expire) * time.Second
+}
+
+// 刷新令牌有效时间
+func (manager *JwtManager) refreshExpire() time.Duration {
+	if manager.config.RefreshExpire <= 0 {
+		return time.Duration(DefaultJwtRefreshExpire) * time.Second
+	}
+	return time.Duration(manager.config.RefreshExpire) * time.Second
+}
+
+// 签发令牌，使用 activeKey
+// 未设置时填充 jti, iss, aud, iat, exp
+func (manager *JwtManager) Sign(claims *JwtClaims) (string, error) {
+	now := time.Now()
+	value := *claims
+	if value.Id == "" {
+		value.Id = Tokens{}.MakeToken()
+	}
+	if value.Issuer == "" {
+		value.Issuer = manager.config.Issuer
+	}
+	if len(value.Audience) == 0 && len(manager.config.Audience) > 0 {
+		value.Audience = manager.config.Audience
+	}
+	if value.IssuedAt == 0 {
+		value.IssuedAt = now.Unix()
+	}
+	if value.ExpiresAt == 0 {
+		value.ExpiresAt = now.Add(manager.expire()).Unix()
+	}
+
+	key := manager.active
+	headerBytes, err := json.Marshal(jwtHeader{Alg: key.alg, Typ: "JWT", Kid: key.kid})
+	if err != nil {
+		return "", err
+	}
+	claimsBytes, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	content := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(claimsBytes)
+	sig, err := key.sign(content)
+	if err != nil {
+		return "", err
+	}
+	return content + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// 校验令牌的签名以及 exp, nbf, iss, aud
+// 失败时返回401的 StatusError
+func (manager *JwtManager) Verify(token string) (*JwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, NewStatusError(401, "invalid token")
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, NewStatusError(401, "invalid token")
+	}
+	header := jwtHeader{}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, NewStatusError(401, "invalid token")
+	}
+	// 没有kid时使用 activeKey，算法必须与密钥一致
+	key := manager.active
+	if header.Kid != "" {
+		key = manager.keys[header.Kid]
+	}
+	if key == nil || header.Alg != key.alg {
+		return nil, NewStatusError(401, "invalid token key")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !key.verify(parts[0]+"."+parts[1], sig) {
+		return nil, NewStatusError(401, "invalid token signature")
+	}
+	claimsBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, NewStatusError(401, "invalid token")
+	}
+	claims := &JwtClaims{}
+	if err := json.Unmarshal(claimsBytes, claims); err != nil {
+		return nil, NewStatusError(401, "invalid token claims")
+	}
+
+	now := time.Now().Unix()
+	leeway := int64(manager.config.Leeway)
+	if claims.ExpiresAt == 0 || now > claims.ExpiresAt+leeway {
+		return nil, NewStatusError(401, "token expired")
+	}
+	if claims.NotBefore != 0 && now+leeway < claims.NotBefore {
+		return nil, NewStatusError(401, "token not active")
+	}
+	if manager.config.Issuer != "" && claims.Issuer != manager.config.Issuer {
+		return nil, NewStatusError(401, "invalid token issuer")
+	}
+	if len(manager.config.Audience) > 0 {
+		matched := false
+		for _, item := range claims.Audience {
+			if SliceContains(manager.config.Audience, item) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, NewStatusError(401, "invalid token audience")
+		}
+	}
+	return claims, nil
+}
+
+// 签发访问令牌和刷新令牌
+func (manager *JwtManager) IssuePair(claims *JwtClaims) (*JwtTokenPair, error) {
+	access := *claims
+	access.Type = ""
+	accessToken, err := manager.Sign(&access)
+	if err != nil {
+		return nil, err
+	}
+	refresh := *claims
+	refresh.Id = ""
+	refresh.Type = JwtTypeRefresh
+	refresh.ExpiresAt = time.Now().Add(manager.refreshExpire()).Unix()
+	refreshToken, err := manager.Sign(&refresh)
+	if err != nil {
+		return nil, err
+	}
+	return &JwtTokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(manager.expire() / time.Second),
+	}, nil
+}
+
+// 使用刷新令牌签发新的访问令牌和刷新令牌，刷新令牌使用一次后失效
+func (manager *JwtManager) Refresh(refreshToken string) (*JwtTokenPair, error) {
+	claims, err := manager.Verify(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != JwtTypeRefresh {
+		return nil, NewStatusError(401, "invalid refresh token")
+	}
+	ok, err := manager.revoke(claims)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, NewStatusError(401, "refresh token already used")
+	}
+	next := *claims
+	next.Id = ""
+	next.IssuedAt = 0
+	next.ExpiresAt = 0
+	next.NotBefore = 0
+	return manager.IssuePair(&next)
+}
+
+// 使刷新令牌失效，例如退出登录时
+func (manager *JwtManager) Revoke(refreshToken string) error {
+	claims, err := manager.Verify(refreshToken)
+	if err != nil {
+		return err
+	}
+	if claims.Type != JwtTypeRefresh {
+		return NewStatusError(401, "invalid refresh token")
+	}
+	_, err = manager.revoke(claims)
+	return err
+}
+
+// 记录已使用的刷新令牌，保存到令牌过期，已经记录过时返回false
+func (manager *JwtManager) revoke(claims *JwtClaims) (bool, error) {
+	if claims.Id == "" {
+		return false, NewStatusError(401, "invalid refresh token")
+	}
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0)) + time.Duration(manager.config.Leeway)*time.Second
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	if manager.redis != nil {
+		return manager.redis.Redis.SetNX(manager.redis.Context, jwtRedisKeyPrefix+claims.Id, 1, ttl).Result()
+	}
+
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	now := time.Now().Unix()
+	for key, expireAt := range manager.revoked {
+		if now > expireAt {
+			delete(manager.revoked, key)
+		}
+	}
+	if _, ok := manager.revoked[claims.Id]; ok {
+		return false, nil
+	}
+	manager.revoked[claims.Id] = time.Now().Add(ttl).Unix()
+	return true, nil
+}
+
+// JWT认证中间件
+func (boot *GobootApplication) jwtMiddleware(config Jwt) gin.HandlerFunc {
+	if !config.Enable {
+		return NextHandler
+	}
+	tokenName := config.TokenName
+	if tokenName == "" {
+		tokenName = DefaultAuthTokenName
+	}
+	LogInfo("goboot enable jwt, token name: %v", tokenName)
+	return func(c *gin.Context) {
+		// 已经通过令牌认证
+		if GetPrincipal(c) != nil {
+			c.Next()
+			return
+		}
+		// 令牌认证需要认证的路径，也可以使用JWT认证
+		required := boot.matchRequestIncludeExclude(config.Includes, config.Excludes, c) || c.GetBool(contextAuthRequiredKey)
+		token := findAuthToken(c, tokenName)
+		if token == "" || boot.JwtManager == nil {
+			if required {
+				boot.HandleError(c, NewStatusError(401, "unauthorized"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		claims, err := boot.JwtManager.Verify(token)
+		if err == nil && claims.Type == JwtTypeRefresh {
+			err = NewStatusError(401, "refresh token not allowed")
+		}
+		if err != nil {
+			if required {
+				boot.HandleError(c, err)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Set(ContextJwtClaimsKey, claims)
+		principal := claims.Principal()
+		principal.Token = token
+		boot.setPrincipal(c, principal)
+		c.Next()
+	}
+}
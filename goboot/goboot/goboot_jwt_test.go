@@ -0,0 +1,226 @@
+package goboot
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newJwtTestManager(t *testing.T, config Jwt) *JwtManager {
+	manager, err := NewJwtManager(config, nil)
+	if err != nil {
+		t.Fatalf("create jwt manager error: %v", err)
+	}
+	return manager
+}
+
+func jwtTestError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestJwtSignVerify(t *testing.T) {
+	manager := newJwtTestManager(t, Jwt{
+		Issuer:   "goboot",
+		Audience: []string{"app"},
+		Keys:     []JwtKey{{Kid: "k1", Alg: "HS256", Secret: "secret"}},
+	})
+	token, err := manager.Sign(&JwtClaims{Subject: "1", Name: "tom", Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("sign error: %v", err)
+	}
+	claims, err := manager.Verify(token)
+	if err != nil || claims.Subject != "1" || claims.Issuer != "goboot" || claims.Audience[0] != "app" || claims.Id == "" {
+		t.Fatalf("claims = %+v, %v", claims, err)
+	}
+
+	expired, _ := manager.Sign(&JwtClaims{Subject: "1", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	future, _ := manager.Sign(&JwtClaims{Subject: "1", NotBefore: time.Now().Add(time.Hour).Unix()})
+	otherAud, _ := manager.Sign(&JwtClaims{Subject: "1", Audience: JwtAudience{"web"}})
+	parts := strings.Split(token, ".")
+	cases := map[string]string{
+		expired:                           "token expired",
+		future:                            "token not active",
+		otherAud:                          "invalid token audience",
+		parts[0] + "." + parts[1] + ".xx": "invalid token signature",
+		"a.b":                             "invalid token",
+	}
+	for item, want := range cases {
+		if _, err := manager.Verify(item); jwtTestError(err) != want {
+			t.Errorf("verify error = %v, want %v", err, want)
+		}
+	}
+
+	other := newJwtTestManager(t, Jwt{Keys: []JwtKey{{Kid: "k1", Alg: "HS256", Secret: "other"}}})
+	if _, err := other.Verify(token); jwtTestError(err) != "invalid token signature" {
+		t.Fatalf("verify with other secret error = %v", err)
+	}
+}
+
+func TestJwtKeyRotation(t *testing.T) {
+	pemFile := filepath.Join(t.TempDir(), "ec.pem")
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []JwtKey{
+		{Kid: "k1", Alg: "HS256", Secret: "secret"},
+		{Kid: "k2", Alg: "ES256", PrivateKeyPath: pemFile},
+	}
+	before := newJwtTestManager(t, Jwt{ActiveKey: "k1", Keys: keys})
+	after := newJwtTestManager(t, Jwt{ActiveKey: "k2", Keys: keys})
+	oldToken, _ := before.Sign(&JwtClaims{Subject: "1"})
+	newToken, err := after.Sign(&JwtClaims{Subject: "2"})
+	if err != nil {
+		t.Fatalf("sign with ES256 error: %v", err)
+	}
+	for _, item := range []string{oldToken, newToken} {
+		if _, err := after.Verify(item); err != nil {
+			t.Errorf("verify after rotation error: %v", err)
+		}
+	}
+	removed := newJwtTestManager(t, Jwt{Keys: keys[1:]})
+	if _, err := removed.Verify(oldToken); jwtTestError(err) != "invalid token key" {
+		t.Fatalf("verify with removed key error = %v", err)
+	}
+
+	if _, err := NewJwtManager(Jwt{ActiveKey: "k3", Keys: keys}, nil); err == nil {
+		t.Fatalf("expected error for unknown activeKey")
+	}
+}
+
+func TestJwtRefresh(t *testing.T) {
+	manager := newJwtTestManager(t, Jwt{Keys: []JwtKey{{Kid: "k1", Alg: "HS512", Secret: "secret"}}})
+	pair, err := manager.IssuePair(&JwtClaims{Subject: "1"})
+	if err != nil {
+		t.Fatalf("issue error: %v", err)
+	}
+	if _, err := manager.Refresh(pair.AccessToken); jwtTestError(err) != "invalid refresh token" {
+		t.Fatalf("refresh with access token error = %v", err)
+	}
+	next, err := manager.Refresh(pair.RefreshToken)
+	if err != nil || next.AccessToken == pair.AccessToken {
+		t.Fatalf("refresh = %+v, %v", next, err)
+	}
+	if _, err := manager.Refresh(pair.RefreshToken); jwtTestError(err) != "refresh token already used" {
+		t.Fatalf("refresh twice error = %v", err)
+	}
+	if err := manager.Revoke(next.RefreshToken); err != nil {
+		t.Fatalf("revoke error: %v", err)
+	}
+	if _, err := manager.Refresh(next.RefreshToken); jwtTestError(err) != "refresh token already used" {
+		t.Fatalf("refresh after revoke error = %v", err)
+	}
+}
+
+type jwtTestApi struct{}
+
+func (api *jwtTestApi) Admin_Info(principal *Principal) string { return "admin " + principal.Id }
+func (api *jwtTestApi) Jwt_Info(principal *Principal) string   { return "jwt " + principal.Id }
+func (api *jwtTestApi) Free() string                           { return "free" }
+
+func (api *jwtTestApi) XP_Login(boot *GobootApplication, c *gin.Context) (string, error) {
+	return boot.Login(c, &Principal{Id: "token"})
+}
+
+func (api *jwtTestApi) XP_JwtLogin(boot *GobootApplication) (string, error) {
+	return boot.JwtManager.Sign(&JwtClaims{Subject: "jwt"})
+}
+
+func TestAuthWithJwt(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.Mapping.Enable = true
+	config.Goboot.Server.Mapping.Items = []string{"/api/"}
+	config.Goboot.Server.Auth = Auth{
+		Enable:   true,
+		Includes: []string{"/api/admin/**"},
+	}
+	config.Goboot.Server.Jwt = Jwt{
+		Enable:   true,
+		Keys:     []JwtKey{{Kid: "k1", Alg: "HS256", Secret: strings.Repeat("s", 32)}},
+		Includes: []string{"/api/jwt/**"},
+	}
+	boot := GetConfigApplication(config, nil)
+	if len(boot.startupErrors) > 0 {
+		t.Fatalf("startup errors: %v", boot.startupErrors)
+	}
+	boot.AddHandlers(&jwtTestApi{})
+	boot.compileMappings()
+
+	opaque := serveAuthTest(boot, "POST", "/api/login", "").Body.String()
+	jwt := serveAuthTest(boot, "POST", "/api/jwt-login", "").Body.String()
+	if strings.Count(jwt, ".") != 2 || strings.Contains(opaque, ".") {
+		t.Fatalf("tokens = %v, %v", opaque, jwt)
+	}
+	refresh, _ := boot.JwtManager.IssuePair(&JwtClaims{Subject: "jwt"})
+
+	cases := []struct {
+		target string
+		token  string
+		code   int
+		body   string
+	}{
+		{"/api/admin/info", opaque, 200, "admin token"},
+		{"/api/admin/info", jwt, 200, "admin jwt"},
+		{"/api/Admin_Info", jwt, 200, "admin jwt"},
+		{"/api/admin/info", "", 401, ""},
+		{"/api/admin/info", "a.b.c", 401, ""},
+		{"/api/admin/info", refresh.RefreshToken, 401, ""},
+		{"/api/admin/info", "bad", 401, ""},
+		{"/api/jwt/info", jwt, 200, "jwt jwt"},
+		{"/api/jwt/info", opaque, 200, "jwt token"},
+		{"/api/Jwt_Info", "", 401, ""},
+		{"/api/jwt/info", "bad", 401, ""},
+		{"/api/free", "a.b.c", 200, "free"},
+		{"/api/free", "bad", 200, "free"},
+	}
+	for _, item := range cases {
+		rec := serveAuthTest(boot, "GET", item.target, item.token)
+		if rec.Code != item.code || (item.body != "" && rec.Body.String() != item.body) {
+			t.Errorf("%v with token %q = %v %v, want %v %v", item.target, item.token, rec.Code, rec.Body.String(), item.code, item.body)
+		}
+	}
+}
+
+func TestValidateJwtSecret(t *testing.T) {
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.Jwt = Jwt{
+		Enable: true,
+		Keys: []JwtKey{
+			{Kid: "k1", Alg: "HS256", Secret: "short"},
+			{Kid: "k2", Alg: "HS256", Secret: "please-change-this-jwt-secret-key"},
+			{Kid: "k3", Alg: "HS256", Secret: strings.Repeat("s", 32)},
+		},
+	}
+	errs := ValidateGobootConfig(config)
+	for _, key := range []string{"goboot.server.jwt.keys.0.secret", "goboot.server.jwt.keys.1.secret"} {
+		if !containsConfigError(errs, key) {
+			t.Errorf("expected %v error, got %v", key, errs)
+		}
+	}
+	if containsConfigError(errs, "goboot.server.jwt.keys.2") {
+		t.Errorf("unexpected error for private secret: %v", errs)
+	}
+}
@@ -0,0 +1,550 @@
+package goboot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 日志区
+// 基于 log/slog 的分级日志，Log/LogInfo/LogWarn/LogError 是默认日志器的简单封装
+// 日志级别：debug, info, warn, error
+// 使用 GetLogger(名称) 获取命名日志器，可以使用 goboot.logging.levels 按名称覆盖日志级别
+// goboot 内部使用的名称：goboot, gin
+// 输出格式：console(默认，与之前的控制台格式一致), text(slog文本), json
+// 支持输出到文件，按大小或者按天切分，并按数量和天数清理旧文件
+//
+// 也可以使用 SetLogger 替换默认日志器，使用 SetLogHandler 替换命名日志器的输出
+// /////////////////////////////////////////////////////////
+
+// 默认日志器名称
+const DefaultLoggerName string = "goboot"
+
+// 日志配置
+type Logging struct {
+	Level          string            `yaml:"level"`          // 日志级别，默认info
+	Levels         map[string]string `yaml:"levels"`         // 按日志器名称覆盖日志级别
+	Format         string            `yaml:"format"`         // 输出格式，console/text/json，默认console
+	DisableConsole bool              `yaml:"disableConsole"` // 是否禁止输出到控制台，开启文件输出时有效
+	File           LoggingFile       `yaml:"file"`
+}
+
+// 日志文件配置
+type LoggingFile struct {
+	Enable     bool   `yaml:"enable"`
+	Path       string `yaml:"path"`       // 日志文件路径
+	MaxSize    int    `yaml:"maxSize"`    // 单个文件最大大小，单位MB，0不按大小切分
+	Daily      bool   `yaml:"daily"`      // 是否按天切分
+	MaxBackups int    `yaml:"maxBackups"` // 保留的旧文件数量，0不限制
+	MaxAge     int    `yaml:"maxAge"`     // 保留旧文件的天数，0不限制
+}
+
+// 日志器接口，*slog.Logger 实现了此接口
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// 日志级别名称
+var logLevelNames = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// 解析日志级别名称，兼容 Log 函数中带空格的级别
+func ParseLogLevel(name string) (slog.Level, error) {
+	level, ok := logLevelNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return slog.LevelInfo, fmt.Errorf("un-support log level: %v", name)
+	}
+	return level, nil
+}
+
+// 日志级别的名称
+func LogLevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// 日志级别状态，支持运行时修改
+type logLevelState struct {
+	lock   sync.RWMutex
+	root   slog.LevelVar
+	levels map[string]slog.Level
+}
+
+var (
+	logLevels     = &logLevelState{levels: map[string]slog.Level{}}
+	logHandler    atomic.Pointer[slog.Handler]
+	defaultLogger atomic.Pointer[Logger]
+	// 当前日志文件，重新配置时关闭
+	logFileWriter io.Closer
+	logConfigLock sync.Mutex
+)
+
+func init() {
+	SetLogHandler(NewConsoleLogHandler(os.Stdout, nil))
+	SetLogger(GetLogger(DefaultLoggerName))
+}
+
+// 获取日志器的生效级别
+func (state *logLevelState) level(name string) slog.Level {
+	state.lock.RLock()
+	defer state.lock.RUnlock()
+	// 支持按前缀覆盖，例如 myapp 覆盖 myapp.order
+	for key := name; key != ""; {
+		if level, ok := state.levels[key]; ok {
+			return level
+		}
+		idx := strings.LastIndex(key, ".")
+		if idx < 0 {
+			break
+		}
+		key = key[:idx]
+	}
+	return state.root.Level()
+}
+
+// 设置根日志级别
+func SetLogLevel(level slog.Level) {
+	logLevels.root.Set(level)
+}
+
+// 设置指定日志器的级别，名称为空时设置根级别
+func SetLoggerLevel(name string, level slog.Level) {
+	if name == "" {
+		SetLogLevel(level)
+		return
+	}
+	logLevels.lock.Lock()
+	defer logLevels.lock.Unlock()
+	logLevels.levels[name] = level
+}
+
+// 获取日志级别，包含根级别(名称为空)和所有覆盖的级别
+func GetLogLevels() map[string]string {
+	logLevels.lock.RLock()
+	defer logLevels.lock.RUnlock()
+	ret := map[string]string{"": LogLevelName(logLevels.root.Level())}
+	for name, level := range logLevels.levels {
+		ret[name] = LogLevelName(level)
+	}
+	return ret
+}
+
+// 获取指定日志器的生效级别
+func GetLoggerLevel(name string) slog.Level {
+	return logLevels.level(name)
+}
+
+// 使用配置设置日志级别，会清除之前覆盖的级别
+func ApplyLogLevels(config Logging) error {
+	root := slog.LevelInfo
+	if config.Level != "" {
+		level, err := ParseLogLevel(config.Level)
+		if err != nil {
+			return err
+		}
+		root = level
+	}
+	levels := map[string]slog.Level{}
+	for name, item := range config.Levels {
+		level, err := ParseLogLevel(item)
+		if err != nil {
+			return fmt.Errorf("logger %v: %w", name, err)
+		}
+		levels[name] = level
+	}
+	logLevels.lock.Lock()
+	logLevels.levels = levels
+	logLevels.lock.Unlock()
+	SetLogLevel(root)
+	return nil
+}
+
+// 替换命名日志器的输出处理器
+func SetLogHandler(handler slog.Handler) {
+	logHandler.Store(&handler)
+}
+
+// 替换 Log* 系列函数使用的默认日志器
+func SetLogger(logger Logger) {
+	defaultLogger.Store(&logger)
+}
+
+// 获取 Log* 系列函数使用的默认日志器
+func GetDefaultLogger() Logger {
+	return *defaultLogger.Load()
+}
+
+// 获取命名日志器，按名称应用日志级别
+func GetLogger(name string) *slog.Logger {
+	return slog.New(&namedLogHandler{name: name})
+}
+
+// 根据配置初始化日志输出和级别
+// 可以多次调用，之前打开的日志文件会被关闭
+func ConfigureLogging(config Logging) error {
+	err := ApplyLogLevels(config)
+	if err != nil {
+		return err
+	}
+
+	logConfigLock.Lock()
+	defer logConfigLock.Unlock()
+
+	writers := []io.Writer{}
+	var file *RotateFileWriter
+	if config.File.Enable {
+		file, err = NewRotateFileWriter(config.File)
+		if err != nil {
+			return err
+		}
+		writers = append(writers, file)
+	}
+	if !config.File.Enable || !config.DisableConsole {
+		writers = append(writers, os.Stdout)
+	}
+	writer := io.MultiWriter(writers...)
+
+	var handler slog.Handler
+	// 级别由命名日志器控制，输出处理器不再过滤
+	options := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch strings.ToLower(config.Format) {
+	case "json":
+		handler = slog.NewJSONHandler(writer, options)
+	case "text":
+		handler = slog.NewTextHandler(writer, options)
+	case "", "console":
+		handler = NewConsoleLogHandler(writer, nil)
+	default:
+		if file != nil {
+			file.Close()
+		}
+		return fmt.Errorf("un-support log format: %v", config.Format)
+	}
+	SetLogHandler(handler)
+
+	if logFileWriter != nil {
+		logFileWriter.Close()
+		logFileWriter = nil
+	}
+	if file != nil {
+		logFileWriter = file
+	}
+	return nil
+}
+
+// 命名日志器的处理器
+// 按名称判断级别，输出时委托给当前的日志处理器，因此替换处理器后已有的日志器也会生效
+type namedLogHandler struct {
+	name  string
+	attrs []slog.Attr
+	group string
+}
+
+func (handler *namedLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= logLevels.level(handler.name)
+}
+
+func (handler *namedLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	target := *logHandler.Load()
+	target = target.WithAttrs([]slog.Attr{slog.String("logger", handler.name)})
+	if len(handler.attrs) > 0 {
+		target = target.WithAttrs(handler.attrs)
+	}
+	if handler.group != "" {
+		target = target.WithGroup(handler.group)
+	}
+	return target.Handle(ctx, record)
+}
+
+func (handler *namedLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	ret := *handler
+	if ret.group != "" {
+		attrs = []slog.Attr{slog.Any(ret.group, slog.GroupValue(attrs...))}
+	}
+	ret.attrs = append(append([]slog.Attr{}, handler.attrs...), attrs...)
+	return &ret
+}
+
+func (handler *namedLogHandler) WithGroup(name string) slog.Handler {
+	ret := *handler
+	if ret.group != "" {
+		name = ret.group + "." + name
+	}
+	ret.group = name
+	return &ret
+}
+
+// 控制台格式的日志处理器
+// 格式：[时间] [级别] 消息 key=value
+type ConsoleLogHandler struct {
+	lock   *sync.Mutex
+	writer io.Writer
+	attrs  []slog.Attr
+	group  string
+}
+
+// 创建控制台格式的日志处理器
+func NewConsoleLogHandler(writer io.Writer, attrs []slog.Attr) *ConsoleLogHandler {
+	return &ConsoleLogHandler{
+		lock:   &sync.Mutex{},
+		writer: writer,
+		attrs:  attrs,
+	}
+}
+
+func (handler *ConsoleLogHandler) Enabled(_ context.Context, _ slog.Level) bool {
+	return true
+}
+
+func (handler *ConsoleLogHandler) Handle(_ context.Context, record slog.Record) error {
+	buf := &bytes.Buffer{}
+	level := record.Level.String()
+	fmt.Fprintf(buf, "[%v] [%-5v] %v", record.Time.Format("2006-01-02 15:04:05"), level, record.Message)
+	writeAttr := func(prefix string, attr slog.Attr) {
+		// 默认日志器名称不输出，保持原来的格式
+		if attr.Key == "logger" && attr.Value.String() == DefaultLoggerName {
+			return
+		}
+		fmt.Fprintf(buf, " %v%v=%v", prefix, attr.Key, attr.Value)
+	}
+	for _, attr := range handler.attrs {
+		writeAttr("", attr)
+	}
+	prefix := ""
+	if handler.group != "" {
+		prefix = handler.group + "."
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		writeAttr(prefix, attr)
+		return true
+	})
+	buf.WriteString("\n")
+	handler.lock.Lock()
+	defer handler.lock.Unlock()
+	_, err := handler.writer.Write(buf.Bytes())
+	return err
+}
+
+func (handler *ConsoleLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	ret := *handler
+	ret.attrs = append([]slog.Attr{}, handler.attrs...)
+	for _, attr := range attrs {
+		if ret.group != "" {
+			attr = slog.Attr{Key: ret.group + "." + attr.Key, Value: attr.Value}
+		}
+		ret.attrs = append(ret.attrs, attr)
+	}
+	return &ret
+}
+
+func (handler *ConsoleLogHandler) WithGroup(name string) slog.Handler {
+	ret := *handler
+	if ret.group != "" {
+		name = ret.group + "." + name
+	}
+	ret.group = name
+	return &ret
+}
+
+// 将每次写入的内容作为一条日志输出，用于接管gin等使用 io.Writer 的日志
+type LogWriter struct {
+	logger *slog.Logger
+	level  slog.Level
+	prefix string
+}
+
+// 创建日志写入器，写入内容中的前缀会被去除
+func NewLogWriter(name string, level slog.Level, prefix string) *LogWriter {
+	return &LogWriter{
+		logger: GetLogger(name),
+		level:  level,
+		prefix: prefix,
+	}
+}
+
+func (writer *LogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(strings.TrimPrefix(string(p), writer.prefix))
+	if msg != "" {
+		writer.logger.Log(context.Background(), writer.level, msg)
+	}
+	return len(p), nil
+}
+
+// 支持切分的日志文件
+// 超过大小或者跨天时，将当前文件重命名为 名称-时间.扩展名，然后创建新文件
+type RotateFileWriter struct {
+	lock     sync.Mutex
+	config   LoggingFile
+	file     *os.File
+	size     int64
+	openDate string
+}
+
+// 创建日志文件写入器
+func NewRotateFileWriter(config LoggingFile) (*RotateFileWriter, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("log file path is empty")
+	}
+	writer := &RotateFileWriter{config: config}
+	err := writer.open()
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// 打开日志文件
+func (writer *RotateFileWriter) open() error {
+	err := os.MkdirAll(filepath.Dir(writer.config.Path), 0755)
+	if err != nil {
+		return fmt.Errorf("create log dir error: %w", err)
+	}
+	file, err := os.OpenFile(writer.config.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open log file %v error: %w", writer.config.Path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	writer.file = file
+	writer.size = info.Size()
+	writer.openDate = info.ModTime().Format("20060102")
+	if info.Size() == 0 {
+		writer.openDate = time.Now().Format("20060102")
+	}
+	return nil
+}
+
+func (writer *RotateFileWriter) Write(p []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	if writer.file == nil {
+		return 0, os.ErrClosed
+	}
+	if writer.needRotate(len(p)) {
+		err := writer.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := writer.file.Write(p)
+	writer.size += int64(n)
+	return n, err
+}
+
+// 是否需要切分
+func (writer *RotateFileWriter) needRotate(size int) bool {
+	if writer.size == 0 {
+		return false
+	}
+	if writer.config.MaxSize > 0 && writer.size+int64(size) > int64(writer.config.MaxSize)*1024*1024 {
+		return true
+	}
+	return writer.config.Daily && time.Now().Format("20060102") != writer.openDate
+}
+
+// 切分日志文件
+func (writer *RotateFileWriter) rotate() error {
+	writer.file.Close()
+	writer.file = nil
+	ext := filepath.Ext(writer.config.Path)
+	base := strings.TrimSuffix(writer.config.Path, ext)
+	backup := fmt.Sprintf("%v-%v%v", base, time.Now().Format("20060102-150405.000"), ext)
+	err := os.Rename(writer.config.Path, backup)
+	if err != nil {
+		return fmt.Errorf("rotate log file error: %w", err)
+	}
+	err = writer.open()
+	if err != nil {
+		return err
+	}
+	writer.cleanBackups()
+	return nil
+}
+
+// 按数量和天数清理旧的日志文件
+func (writer *RotateFileWriter) cleanBackups() {
+	if writer.config.MaxBackups <= 0 && writer.config.MaxAge <= 0 {
+		return
+	}
+	ext := filepath.Ext(writer.config.Path)
+	base := strings.TrimSuffix(writer.config.Path, ext)
+	backups, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return
+	}
+	// 文件名中的时间可以直接排序，最新的在前
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	for i, item := range backups {
+		remove := writer.config.MaxBackups > 0 && i >= writer.config.MaxBackups
+		if !remove && writer.config.MaxAge > 0 {
+			info, err := os.Stat(item)
+			remove = err == nil && time.Since(info.ModTime()) > time.Duration(writer.config.MaxAge)*24*time.Hour
+		}
+		if remove {
+			os.Remove(item)
+		}
+	}
+}
+
+// 关闭日志文件
+func (writer *RotateFileWriter) Close() error {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	if writer.file == nil {
+		return nil
+	}
+	err := writer.file.Close()
+	writer.file = nil
+	return err
+}
+
+// 创建gin引擎，gin的日志和异常恢复都使用 gin 日志器输出，不信任任何代理
+// gin 的调试信息(例如路由注册)为 debug 级别
+func NewGinEngine() *gin.Engine {
+	gin.DefaultWriter = NewLogWriter("gin", slog.LevelDebug, "[GIN-debug]")
+	gin.DefaultErrorWriter = NewLogWriter("gin", slog.LevelError, "[GIN-debug]")
+	engine := gin.New()
+	// 默认不信任任何代理，避免伪造 X-Forwarded-For 修改客户端IP
+	engine.SetTrustedProxies(nil)
+	engine.Use(gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: func(param gin.LogFormatterParams) string {
+			return fmt.Sprintf("%3d | %13v | %15s | %-7s %#v %s",
+				param.StatusCode,
+				param.Latency,
+				param.ClientIP,
+				param.Method,
+				param.Path,
+				param.ErrorMessage,
+			)
+		},
+		Output: NewLogWriter("gin", slog.LevelInfo, ""),
+		// 已经输出访问日志的请求不再重复输出
+		Skip: func(c *gin.Context) bool {
+			return c.GetBool(contextAccessLoggedKey)
+		},
+	}))
+	engine.Use(gin.RecoveryWithWriter(NewLogWriter("gin", slog.LevelError, "")))
+	return engine
+}
@@ -0,0 +1,102 @@
+package goboot
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试结束后恢复默认的日志输出和级别
+func resetTestLogging(t *testing.T) {
+	t.Cleanup(func() {
+		ApplyLogLevels(Logging{})
+		SetLogHandler(NewConsoleLogHandler(os.Stdout, nil))
+	})
+}
+
+func TestParseLogLevel(t *testing.T) {
+	level, err := ParseLogLevel("INFO ")
+	if err != nil || level != slog.LevelInfo {
+		t.Fatalf("level = %v, %v", level, err)
+	}
+	if _, err := ParseLogLevel("trace"); err == nil {
+		t.Fatalf("expected error for un-support level")
+	}
+	if LogLevelName(slog.LevelWarn) != "warn" {
+		t.Fatalf("name = %v", LogLevelName(slog.LevelWarn))
+	}
+}
+
+func TestApplyLogLevels(t *testing.T) {
+	resetTestLogging(t)
+	err := ApplyLogLevels(Logging{
+		Level:  "warn",
+		Levels: map[string]string{"myapp": "debug", "myapp.order": "error"},
+	})
+	if err != nil {
+		t.Fatalf("apply error: %v", err)
+	}
+	cases := map[string]slog.Level{
+		"goboot":          slog.LevelWarn,
+		"myapp":           slog.LevelDebug,
+		"myapp.user":      slog.LevelDebug,
+		"myapp.order":     slog.LevelError,
+		"myapp.order.pay": slog.LevelError,
+	}
+	for name, want := range cases {
+		if got := GetLoggerLevel(name); got != want {
+			t.Errorf("logger %v level = %v, want %v", name, got, want)
+		}
+	}
+	if err := ApplyLogLevels(Logging{Levels: map[string]string{"a": "nope"}}); err == nil {
+		t.Fatalf("expected error for un-support level")
+	}
+}
+
+func TestNamedLogger(t *testing.T) {
+	resetTestLogging(t)
+	buf := &bytes.Buffer{}
+	SetLogHandler(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	SetLoggerLevel("myapp", slog.LevelInfo)
+
+	logger := GetLogger("myapp").With("user", "tom")
+	logger.Debug("hidden")
+	logger.Info("shown")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Fatalf("debug message should be filtered: %v", out)
+	}
+	for _, item := range []string{"msg=shown", "logger=myapp", "user=tom"} {
+		if !strings.Contains(out, item) {
+			t.Fatalf("output %v not contains %v", out, item)
+		}
+	}
+}
+
+func TestRotateFileWriter(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "logs", "app.log")
+	writer, err := NewRotateFileWriter(LoggingFile{Path: logPath, MaxSize: 1, MaxBackups: 1})
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+	defer writer.Close()
+
+	chunk := bytes.Repeat([]byte("x"), 600*1024)
+	for i := 0; i < 5; i++ {
+		if _, err := writer.Write(chunk); err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+	}
+	backups, _ := filepath.Glob(filepath.Join(filepath.Dir(logPath), "app-*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want 1 kept", backups)
+	}
+	info, err := os.Stat(logPath)
+	if err != nil || info.Size() != int64(len(chunk)) {
+		t.Fatalf("current log file size = %v, %v", info, err)
+	}
+}
@@ -0,0 +1,563 @@
+package goboot
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 映射路由表区
+// 在 Run 时将 mapping 和 controller 的函数编译为路由表，并注册为gin路由
+// 路由路径为函数名转换后的路径，例如 /api/ + FindUser_GeoRange --> /api/find-user/geo-range
+// 函数名前缀 XG_,XP_,XU_,XD_,XH_ 限定请求方式，XA_ 或者没有前缀为任意请求方式
+// 处理器实现 Routes() 时，可以为函数指定带有路径变量的路由模板
+// 相同路径和请求方式的重复函数，或者任意请求方式与指定请求方式的函数冲突时，启动失败
+//
+// 其他写法的路径，例如 /api/findUser/geoRange, /api/FindUser_GeoRange
+// 不匹配gin路由，在 NoRoute 中按函数名查找路由表处理
+// 函数名存在但请求方式不匹配时响应405
+//
+// 热加载 mapping 时重新构建路由表并替换，gin路由注册后不能移除
+// 移除的前缀响应404，新增的前缀在 NoRoute 中按函数名处理，使用路由模板的函数需要重启后生效
+// /////////////////////////////////////////////////////////
+
+// 路由类型
+const (
+	MappingRouteMapping    string = "mapping"
+	MappingRouteController string = "controller"
+)
+
+// 任意请求方式
+const MappingMethodAny string = "ANY"
+
+// 函数名前缀对应的请求方式
+var mappingMethodPrefixes = map[string]string{
+	"XG_": http.MethodGet,
+	"XP_": http.MethodPost,
+	"XU_": http.MethodPut,
+	"XD_": http.MethodDelete,
+	"XH_": http.MethodPatch,
+	"XA_": MappingMethodAny,
+}
+
+// 编译后的映射路由
+type MappingRoute struct {
+	Type        string      // mapping, controller
+	Method      string      // 请求方式，ANY 表示任意请求方式
+	Path        string      // 完整的路由路径
+	Base        string      // 所属的mapping前缀或者controller路径
+	Name        string      // 去除请求方式前缀的函数名，例如 User_FavIcon
+	FuncName    string      // 函数名，例如 XG_User_FavIcon
+	HandlerName string      // 处理器类型和函数名，例如 Api.XG_User_FavIcon
+	Handler     interface{} // 处理器对象
+	Params      []string    // 路径变量名称，例如 /user/:id 中的 id
+	Permissions []string    // 需要的角色或权限，拥有其中一个即可
+
+	fn       reflect.Value
+	argTypes []reflect.Type
+	// 是否使用 Routes() 中的路由模板
+	templated bool
+}
+
+// 映射路由表
+type mappingTable struct {
+	routes []*MappingRoute
+	// 路径 --> 请求方式 --> 路由
+	byPath map[string]map[string]*MappingRoute
+	// 前缀 + 函数名 --> 请求方式 --> 路由
+	byName map[string]map[string]*MappingRoute
+	// mapping前缀和controller路径
+	bases []string
+}
+
+func newMappingTable() *mappingTable {
+	return &mappingTable{
+		byPath: map[string]map[string]*MappingRoute{},
+		byName: map[string]map[string]*MappingRoute{},
+	}
+}
+
+// 添加路由，重复或者冲突时返回错误
+func (table *mappingTable) add(route *MappingRoute) error {
+	methods := table.byPath[route.Path]
+	for method, exists := range methods {
+		if method == route.Method {
+			return fmt.Errorf("duplicate mapping %v %v, both %v and %v", route.Method, route.Path, exists.HandlerName, route.HandlerName)
+		}
+		if method == MappingMethodAny || route.Method == MappingMethodAny {
+			return fmt.Errorf("ambiguous mapping %v, %v %v conflict with %v %v", route.Path, exists.Method, exists.HandlerName, route.Method, route.HandlerName)
+		}
+	}
+	if methods == nil {
+		methods = map[string]*MappingRoute{}
+		table.byPath[route.Path] = methods
+	}
+	methods[route.Method] = route
+
+	// 使用路由模板的函数，只能通过模板路径访问
+	if !route.templated {
+		key := route.Base + "\x00" + route.Name
+		if table.byName[key] == nil {
+			table.byName[key] = map[string]*MappingRoute{}
+		}
+		table.byName[key][route.Method] = route
+	}
+	table.routes = append(table.routes, route)
+	return nil
+}
+
+// 按照路径和请求方式查找路由
+func (table *mappingTable) find(urlPath string, method string) *MappingRoute {
+	methods := table.byPath[urlPath]
+	if route, ok := methods[method]; ok {
+		return route
+	}
+	return methods[MappingMethodAny]
+}
+
+// 按照函数名查找路由
+// 第二个返回值为函数名存在时允许的请求方式
+func (table *mappingTable) findByName(base string, name string, method string) (*MappingRoute, []string) {
+	methods := table.byName[base+"\x00"+name]
+	if route, ok := methods[method]; ok {
+		return route, nil
+	}
+	if route, ok := methods[MappingMethodAny]; ok {
+		return route, nil
+	}
+	allows := []string{}
+	for item := range methods {
+		allows = append(allows, item)
+	}
+	sort.Strings(allows)
+	return nil, allows
+}
+
+// 解析函数名中的请求方式前缀，返回请求方式和去除前缀的函数名
+// 没有前缀时为任意请求方式
+func ParseMappingFuncName(funcName string) (string, string) {
+	if len(funcName) >= 3 {
+		if method, ok := mappingMethodPrefixes[funcName[:3]]; ok {
+			return method, funcName[3:]
+		}
+	}
+	return MappingMethodAny, funcName
+}
+
+// URL路径转换为函数名
+// 每一级路径按照横线分隔后使用大驼峰组合，再使用下划线连接
+// 例如 find-user/geo-range --> FindUser_GeoRange
+func MappingMethodName(proxyPath string) string {
+	paths := strings.Split(proxyPath, "/")
+	methodName := ""
+
+	for _, path := range paths {
+		item := strings.Trim(path, " \t\n\r")
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, "-")
+		item = ""
+		for _, part := range parts {
+			if part == "" {
+				continue
+			}
+			item += strings.ToUpper(part[:1]) + part[1:]
+		}
+		if item == "" {
+			continue
+		}
+
+		item = strings.ToUpper(item[:1]) + item[1:]
+		if methodName != "" {
+			methodName += "_"
+		}
+		methodName += item
+	}
+	return methodName
+}
+
+// 函数名转换为路径，例如 FindUser_GeoRange --> find-user/geo-range
+// 是 MappingMethodName 的逆转换
+func MappingMethodPath(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		builder := strings.Builder{}
+		for j, ch := range part {
+			if ch >= 'A' && ch <= 'Z' {
+				if j > 0 {
+					builder.WriteByte('-')
+				}
+				ch = ch - 'A' + 'a'
+			}
+			builder.WriteRune(ch)
+		}
+		parts[i] = builder.String()
+	}
+	return strings.Join(parts, "/")
+}
+
+// 拼接基础路径和相对路径
+func joinMappingPath(base string, subPath string) string {
+	return strings.TrimSuffix(base, "/") + "/" + subPath
+}
+
+// 获取处理器中可以映射的路由，路径为相对路径，未设置类型
+// 处理器必须是结构体或者结构体的指针
+func mappingHandlerRoutes(boot *GobootApplication, handler interface{}) ([]*MappingRoute, error) {
+	ret := []*MappingRoute{}
+	htype := reflect.TypeOf(handler)
+	rtype := htype
+	if htype.Kind() == reflect.Ptr && htype.Elem().Kind() == reflect.Struct {
+		rtype = htype.Elem()
+	}
+	if rtype.Kind() != reflect.Struct {
+		return ret, fmt.Errorf("mapping handler require struct or struct pointer, but got %v", htype)
+	}
+	templates, err := parseMappingTemplates(handler)
+	if err != nil {
+		return ret, fmt.Errorf("%v.Routes() %w", rtype.Name(), err)
+	}
+	permissions := parseMappingPermissions(handler)
+	if err := checkMappingPermissions(htype, permissions); err != nil {
+		return ret, fmt.Errorf("%v.Permissions() %w", rtype.Name(), err)
+	}
+	_, isController := handler.(GobootController)
+	hval := reflect.ValueOf(handler)
+	for i := 0; i < htype.NumMethod(); i++ {
+		mm := htype.Method(i)
+		if isController && mm.Name == "Path" {
+			continue
+		}
+		if _, ok := handler.(MappingRouter); ok && mm.Name == "Routes" {
+			continue
+		}
+		if _, ok := handler.(PermissionDeclarer); ok && mm.Name == "Permissions" {
+			continue
+		}
+		method, name := ParseMappingFuncName(mm.Name)
+		fn := hval.Method(i)
+
+		// 使用路由模板，或者函数名转换的路径
+		items := templates[mm.Name]
+		if name != mm.Name {
+			items = append(items, templates[name]...)
+		}
+		delete(templates, mm.Name)
+		delete(templates, name)
+		templated := len(items) > 0
+		if !templated {
+			items = []mappingTemplate{{Method: method, Path: MappingMethodPath(name)}}
+		}
+
+		for _, item := range items {
+			route := &MappingRoute{
+				Method:      item.Method,
+				Path:        item.Path,
+				Name:        name,
+				FuncName:    mm.Name,
+				HandlerName: rtype.Name() + "." + mm.Name,
+				Handler:     handler,
+				Params:      mappingPathParams(item.Path),
+				Permissions: mappingFuncPermissions(permissions, mm.Name, name),
+				fn:          fn,
+				templated:   templated,
+			}
+			if route.Method == "" {
+				route.Method = method
+			} else if method != MappingMethodAny && route.Method != method {
+				return ret, fmt.Errorf("%v route %v %v conflict with method prefix of %v", rtype.Name(), item.Method, item.Path, mm.Name)
+			}
+			err := route.resolveArgTypes(boot)
+			if err != nil && templated {
+				return ret, err
+			}
+			if err != nil {
+				LogWarn("goboot mapping skip %v, %v", route.HandlerName, err)
+				continue
+			}
+			ret = append(ret, route)
+		}
+	}
+	for key := range templates {
+		return ret, fmt.Errorf("%v.Routes() function %v not found", rtype.Name(), key)
+	}
+	return ret, nil
+}
+
+// 检查函数的入参类型，普通类型的参数按照顺序注入路径变量，已经注册注入器的类型除外
+func (route *MappingRoute) resolveArgTypes(boot *GobootApplication) error {
+	route.argTypes = []reflect.Type{}
+	pathArgs := 0
+	for p := 0; p < route.fn.Type().NumIn(); p++ {
+		arg := route.fn.Type().In(p)
+		if boot.isMappingPathArg(arg) {
+			pathArgs++
+			if pathArgs > len(route.Params) {
+				return fmt.Errorf("argument %v require path variable, but %v has %v path variable(s)", arg, route.Path, len(route.Params))
+			}
+		} else if !mappingArgSupported(boot, arg) {
+			return fmt.Errorf("not support argument type: %v", arg)
+		}
+		route.argTypes = append(route.argTypes, arg)
+	}
+	return nil
+}
+
+// 参数类型是否可以被 HandleMappingMethodArg 注入
+func mappingArgSupported(boot *GobootApplication, arg reflect.Type) bool {
+	if _, ok := boot.argInjector(arg); ok {
+		return true
+	}
+	if boot.hasService(arg) {
+		return true
+	}
+	if isMappingSourceArg(arg) {
+		return true
+	}
+	if _, ok := boot.ConfigBeans[arg]; ok {
+		return true
+	}
+	if _, ok := boot.ConfigBeans[reflect.PtrTo(arg)]; ok {
+		return true
+	}
+	if arg.Kind() == reflect.Ptr {
+		return arg.Elem().Kind() == reflect.Struct
+	}
+	return arg.Kind() == reflect.Struct
+}
+
+// 编译mapping和controller的路由表，并注册为gin路由
+// 在 Run 时调用，错误记录为启动错误
+func (boot *GobootApplication) compileMappings() {
+	if boot.mappings.Load() != nil {
+		return
+	}
+	table, errs := boot.buildMappingTable(boot.Config.Goboot.Server.Mapping)
+	boot.startupErrors = append(boot.startupErrors, errs...)
+	boot.mappings.Store(table)
+
+	for _, route := range table.routes {
+		err := boot.registerMappingRoute(route)
+		if err != nil {
+			boot.startupErrors = append(boot.startupErrors, fmt.Sprintf("goboot.%v: %v", route.Type, err))
+			continue
+		}
+		LogInfo("goboot %v route: %v %v --> %v", route.Type, route.Method, route.Path, route.HandlerName)
+	}
+}
+
+// 按照mapping配置和控制器构建路由表，返回路由表和错误
+func (boot *GobootApplication) buildMappingTable(mapping Mapping) (*mappingTable, []string) {
+	table := newMappingTable()
+	errs := []string{}
+
+	addRoutes := func(routeType string, base string, handler interface{}) {
+		routes, err := mappingHandlerRoutes(boot, handler)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("goboot.%v %v: %v", routeType, base, err))
+			return
+		}
+		if !SliceContains(table.bases, base) {
+			table.bases = append(table.bases, base)
+		}
+		for _, route := range routes {
+			route.Type = routeType
+			route.Base = base
+			route.Path = joinMappingPath(base, route.Path)
+			err := table.add(route)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("goboot.%v: %v", routeType, err))
+			}
+		}
+	}
+
+	if mapping.Enable {
+		LogInfo("goboot enbale %v mapping(s)", len(mapping.Items))
+		for _, item := range mapping.Items {
+			LogInfo("goboot mapping, path: %v", item)
+			for _, handler := range boot.Handlers {
+				addRoutes(MappingRouteMapping, item, handler)
+			}
+		}
+	}
+	if len(boot.Controllers) > 0 {
+		LogInfo("goboot enbale %v controllers(s)", len(boot.Controllers))
+		for _, item := range boot.Controllers {
+			addRoutes(MappingRouteController, item.Path(), item)
+		}
+	}
+	return table, errs
+}
+
+// 注册gin路由，gin的路由冲突转换为错误
+// gin路由注册后不能移除，请求时使用当前路由表中相同路径的路由
+// 热加载移除了路由的前缀时，响应404
+func (boot *GobootApplication) registerMappingRoute(route *MappingRoute) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("register route %v %v of %v failure, %v", route.Method, route.Path, route.HandlerName, rec)
+		}
+	}()
+	handler := func(c *gin.Context) {
+		current := boot.mappings.Load().find(route.Path, c.Request.Method)
+		if current == nil {
+			boot.notFoundHandler(c)
+			return
+		}
+		boot.invokeMappingRoute(c, current)
+	}
+	if route.Method == MappingMethodAny {
+		boot.App.Any(route.Path, handler)
+	} else {
+		boot.App.Handle(route.Method, route.Path, handler)
+	}
+	return nil
+}
+
+// 调用映射路由的函数
+// panic 和返回的错误交给错误处理器链
+func (boot *GobootApplication) invokeMappingRoute(c *gin.Context, route *MappingRoute) {
+	c.Set(ContextMappingMethodKey, route.HandlerName)
+	// NoRoute 中按函数名处理时状态码已经是404，重置为200
+	// 之后只保留处理器或拦截器设置的状态码
+	if !c.Writer.Written() {
+		c.Status(http.StatusOK)
+	}
+	// 函数执行后关闭请求作用域的服务
+	defer boot.closeRequestServices(c)
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+		// 由 net/http 处理的中断请求
+		if rec == http.ErrAbortHandler {
+			panic(rec)
+		}
+		boot.HandleError(c, NewPanicError(rec))
+	}()
+
+	// 在参数注入之前检查限流和权限
+	if !boot.checkRouteRateLimit(c, route) {
+		return
+	}
+	if len(route.Permissions) > 0 {
+		if err := boot.checkRoutePermissions(c, route); err != nil {
+			boot.HandleError(c, err)
+			return
+		}
+	}
+
+	callArgs := make([]reflect.Value, 0, len(route.argTypes))
+	pathArgs := 0
+	// 为每个函数入参注入值
+	for _, arg := range route.argTypes {
+		// 普通类型的参数按照顺序注入路径变量
+		if boot.isMappingPathArg(arg) {
+			val, err := boot.convertPathParam(c, route.Params[pathArgs], arg)
+			if err != nil {
+				boot.HandleError(c, err)
+				return
+			}
+			pathArgs++
+			callArgs = append(callArgs, val)
+			continue
+		}
+		val, ok, err := resolveMappingMethodArg(arg, boot, c)
+		if err != nil {
+			// 请求参数绑定或校验失败，不调用函数
+			boot.HandleError(c, err)
+			return
+		}
+		if !ok {
+			boot.HandleError(c, fmt.Errorf("mapping %v not support argument type: %v", route.HandlerName, arg))
+			return
+		}
+		callArgs = append(callArgs, val)
+	}
+	// 经过拦截器链调用函数
+	results := boot.interceptMappingRoute(c, route, callArgs)
+	if results == nil {
+		return
+	}
+	// 处理返回值
+	boot.handleMappingResult(c, route, results)
+}
+
+// 按函数名处理不匹配gin路由的映射请求
+// 返回是否已经处理
+func (boot *GobootApplication) handleMappingByName(c *gin.Context, base string, proxyPath string) bool {
+	table := boot.mappings.Load()
+	if table == nil {
+		return false
+	}
+	route, allows := table.findByName(base, MappingMethodName(proxyPath), c.Request.Method)
+	if route != nil {
+		LogDebug("goboot mapping, path: %v --> %v", c.Request.URL.Path, route.HandlerName)
+		boot.invokeMappingRoute(c, route)
+		return true
+	}
+	if len(allows) > 0 {
+		boot.methodNotAllowed(c, allows)
+		return true
+	}
+	return false
+}
+
+// 按函数名查找不匹配gin路由的请求对应的映射路由，没有时返回nil
+// 认证等按路径判断的中间件使用路由的路径，避免其他写法的路径绕过判断
+func (boot *GobootApplication) mappingFallbackRoute(c *gin.Context) *MappingRoute {
+	table := boot.mappings.Load()
+	if table == nil || c.FullPath() != "" {
+		return nil
+	}
+	urlPath := c.Request.URL.Path
+	for _, base := range table.bases {
+		if !strings.HasPrefix(urlPath, base) {
+			continue
+		}
+		route, _ := table.findByName(base, MappingMethodName(urlPath[len(base):]), c.Request.Method)
+		if route != nil {
+			return route
+		}
+	}
+	return nil
+}
+
+// 请求是否匹配路径模式，同时使用请求路径和按函数名匹配的映射路由的路径
+func (boot *GobootApplication) matchRequestIncludeExclude(includes []string, excludes []string, c *gin.Context) bool {
+	if matchIncludeExclude(includes, excludes, c.Request.URL.Path) {
+		return true
+	}
+	route := boot.mappingFallbackRoute(c)
+	return route != nil && matchIncludeExclude(includes, excludes, route.Path)
+}
+
+// 映射请求的 NoRoute 处理，按函数名匹配其他写法的路径
+func (boot *GobootApplication) mappingNoRouteHandler(c *gin.Context) {
+	if table := boot.mappings.Load(); table != nil {
+		urlPath := c.Request.URL.Path
+		for _, base := range table.bases {
+			if strings.HasPrefix(urlPath, base) && boot.handleMappingByName(c, base, urlPath[len(base):]) {
+				c.Abort()
+				return
+			}
+		}
+	}
+	c.Next()
+}
+
+// 获取编译后的映射路由，Run 之前为空
+func (boot *GobootApplication) MappingRoutes() []*MappingRoute {
+	table := boot.mappings.Load()
+	if table == nil {
+		return []*MappingRoute{}
+	}
+	return append([]*MappingRoute{}, table.routes...)
+}
@@ -0,0 +1,434 @@
+package goboot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 参数注入区
+// 映射函数的入参按照类型注入，内置支持的类型：
+// *gin.Context, *CtxResp, *ApiResp, *http.Request, *GobootApplication, *gin.Engine
+// *RedisCli, *redis.Client, *gorm.DB, *sql.DB，未开启时为nil
+// context.Context 为请求的上下文
+// *Principal 为认证的当前用户，*JwtClaims 为JWT声明，未认证时为nil
+// sessions.Session 未开启session时为只在当前请求内有效的session，Save 返回错误
+// url.Values 为查询参数和表单参数
+// *multipart.FileHeader 为上传的第一个文件，没有上传时响应400，[]*multipart.FileHeader 为上传的全部文件
+// 以及使用 BindConfig 绑定的自定义配置
+//
+// 结构体参数绑定请求参数，字段使用标签指定来源：
+// uri 路径变量，header 请求头，cookie Cookie，query 查询参数，form/json 请求体
+// 也可以使用包装类型只从一个来源绑定，使用 .Value 获取结构体
+// Header[T] 使用 header 标签，Cookie[T] 使用 cookie 标签，Query[T] 和 Form[T] 使用 form 标签
+// func (api *Api) List(page goboot.Query[PageQuery], auth goboot.Header[AuthHeader]) any
+//
+// 使用 RegisterArgInjector 注册自定义类型的注入，需要在 Run 之前注册，例如当前用户：
+//
+//	goboot.RegisterArgInjector(app, func(c *gin.Context) (*User, error) {
+//		user, ok := c.Get("user")
+//		if !ok {
+//			return nil, goboot.NewStatusError(401, "not login")
+//		}
+//		return user.(*User), nil
+//	})
+//
+// /////////////////////////////////////////////////////////
+
+// 参数注入器，返回指定类型的参数值
+// 返回nil时注入类型的零值，返回错误时不调用函数，交给错误处理器链
+type ArgInjector func(c *gin.Context, boot *GobootApplication) (interface{}, error)
+
+// 未开启session时，请求内session的上下文键
+const contextRequestSessionKey string = "goboot.requestSession"
+
+// 内置的参数注入器
+var defaultArgInjectors = map[reflect.Type]ArgInjector{
+	reflect.TypeOf((*gin.Context)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return c, nil
+	},
+	reflect.TypeOf((*CtxResp)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return &CtxResp{
+			Context: c,
+			Session: mappingSession(c, boot),
+			App:     boot,
+		}, nil
+	},
+	reflect.TypeOf((*ApiResp)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return ApiOk(nil), nil
+	},
+	reflect.TypeOf((*http.Request)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return c.Request, nil
+	},
+	reflect.TypeOf((*GobootApplication)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return boot, nil
+	},
+	reflect.TypeOf((*gin.Engine)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return boot.App, nil
+	},
+	reflect.TypeOf((*RedisCli)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return boot.Redis, nil
+	},
+	reflect.TypeOf((*goredis.Client)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		// 未开启redis时为空
+		var redis *goredis.Client
+		if boot.Redis != nil {
+			redis = boot.Redis.Redis
+		}
+		return redis, nil
+	},
+	reflect.TypeOf((*gorm.DB)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return boot.GormDb, nil
+	},
+	reflect.TypeOf((*sql.DB)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return boot.Db, nil
+	},
+	reflect.TypeOf((*Principal)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return GetPrincipal(c), nil
+	},
+	reflect.TypeOf((*JwtClaims)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return GetJwtClaims(c), nil
+	},
+	reflect.TypeOf((*context.Context)(nil)).Elem(): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return c.Request.Context(), nil
+	},
+	reflect.TypeOf((*sessions.Session)(nil)).Elem(): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return mappingSession(c, boot), nil
+	},
+	reflect.TypeOf(url.Values{}): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		err := c.Request.ParseMultipartForm(boot.App.MaxMultipartMemory)
+		if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return nil, boot.newBindError(c, err)
+		}
+		return c.Request.Form, nil
+	},
+	reflect.TypeOf((*multipart.FileHeader)(nil)): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		files, err := mappingUploadFiles(c)
+		if err != nil {
+			return nil, boot.newBindError(c, err)
+		}
+		if len(files) == 0 {
+			locale := boot.validationLocale(c)
+			message := ValidationMessage(locale, "required", "file", "")
+			return nil, &BindError{
+				Msg: ValidationMessage(locale, "_invalid", "", "") + ": " + message,
+				Fields: []FieldError{{
+					Field:   "file",
+					Tag:     "required",
+					Message: message,
+				}},
+				Err: http.ErrMissingFile,
+			}
+		}
+		return files[0], nil
+	},
+	reflect.TypeOf([]*multipart.FileHeader{}): func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		files, err := mappingUploadFiles(c)
+		if err != nil {
+			return nil, boot.newBindError(c, err)
+		}
+		return files, nil
+	},
+}
+
+// 注册参数类型的注入器，覆盖内置的注入器
+func (app *GobootApplication) AddArgInjector(argType reflect.Type, injector ArgInjector) *GobootApplication {
+	if app.argInjectors == nil {
+		app.argInjectors = map[reflect.Type]ArgInjector{}
+	}
+	app.argInjectors[argType] = injector
+	return app
+}
+
+// 注册类型T的注入器
+func RegisterArgInjector[T any](app *GobootApplication, injector func(c *gin.Context) (T, error)) *GobootApplication {
+	argType := reflect.TypeOf((*T)(nil)).Elem()
+	return app.AddArgInjector(argType, func(c *gin.Context, boot *GobootApplication) (interface{}, error) {
+		return injector(c)
+	})
+}
+
+// 获取参数类型的注入器，优先使用注册的注入器
+func (boot *GobootApplication) argInjector(arg reflect.Type) (ArgInjector, bool) {
+	if injector, ok := boot.argInjectors[arg]; ok {
+		return injector, true
+	}
+	injector, ok := defaultArgInjectors[arg]
+	return injector, ok
+}
+
+// 是否为按顺序注入路径变量的参数，已经注册注入器的类型和容器中的服务除外
+func (boot *GobootApplication) isMappingPathArg(arg reflect.Type) bool {
+	if _, ok := boot.argInjector(arg); ok {
+		return false
+	}
+	if boot.hasService(arg) {
+		return false
+	}
+	return isMappingPathArg(arg)
+}
+
+// 调用注入器，将返回值转换为参数类型
+func (boot *GobootApplication) injectMappingArg(c *gin.Context, arg reflect.Type, injector ArgInjector) (reflect.Value, error) {
+	val, err := injector(c, boot)
+	if err != nil {
+		return reflect.Zero(arg), err
+	}
+	if val == nil {
+		return reflect.Zero(arg), nil
+	}
+	rval := reflect.ValueOf(val)
+	if !rval.Type().AssignableTo(arg) {
+		return reflect.Zero(arg), fmt.Errorf("injector of %v returned %v", arg, rval.Type())
+	}
+	ret := reflect.New(arg).Elem()
+	ret.Set(rval)
+	return ret, nil
+}
+
+// 获取上传的全部文件，按照字段名排序，不是multipart请求时为空
+func mappingUploadFiles(c *gin.Context) ([]*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if errors.Is(err, http.ErrNotMultipart) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for key := range form.File {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	ret := []*multipart.FileHeader{}
+	for _, key := range keys {
+		ret = append(ret, form.File[key]...)
+	}
+	return ret, nil
+}
+
+// 获取当前请求的session
+// 未开启session时，返回只在当前请求内有效的session
+func mappingSession(c *gin.Context, boot *GobootApplication) sessions.Session {
+	if boot.Config.Goboot.Server.Session.Enable {
+		return sessions.Default(c)
+	}
+	if val, ok := c.Get(contextRequestSessionKey); ok {
+		return val.(sessions.Session)
+	}
+	session := &requestSession{values: map[interface{}]interface{}{}}
+	c.Set(contextRequestSessionKey, session)
+	return session
+}
+
+// 只在当前请求内有效的session，未开启session时使用
+type requestSession struct {
+	values map[interface{}]interface{}
+}
+
+func (s *requestSession) ID() string {
+	return ""
+}
+
+func (s *requestSession) Get(key interface{}) interface{} {
+	return s.values[key]
+}
+
+func (s *requestSession) Set(key interface{}, val interface{}) {
+	s.values[key] = val
+}
+
+func (s *requestSession) Delete(key interface{}) {
+	delete(s.values, key)
+}
+
+func (s *requestSession) Clear() {
+	s.values = map[interface{}]interface{}{}
+}
+
+func (s *requestSession) AddFlash(value interface{}, vars ...string) {
+	key := "_flash"
+	if len(vars) > 0 {
+		key = vars[0]
+	}
+	flashes, _ := s.values[key].([]interface{})
+	s.values[key] = append(flashes, value)
+}
+
+func (s *requestSession) Flashes(vars ...string) []interface{} {
+	key := "_flash"
+	if len(vars) > 0 {
+		key = vars[0]
+	}
+	flashes, _ := s.values[key].([]interface{})
+	delete(s.values, key)
+	return flashes
+}
+
+func (s *requestSession) Options(options sessions.Options) {
+}
+
+func (s *requestSession) Save() error {
+	return errors.New("session not enabled, set goboot.server.session.enable")
+}
+
+// 指定来源绑定的参数
+type mappingSourceBinder interface {
+	bindMappingSource(boot *GobootApplication, c *gin.Context) error
+}
+
+var mappingSourceBinderType = reflect.TypeOf((*mappingSourceBinder)(nil)).Elem()
+
+// 是否为指定来源的包装类型
+func isMappingSourceArg(arg reflect.Type) bool {
+	if arg.Kind() == reflect.Ptr {
+		return arg.Elem().Kind() == reflect.Struct && arg.Implements(mappingSourceBinderType)
+	}
+	return arg.Kind() == reflect.Struct && reflect.PtrTo(arg).Implements(mappingSourceBinderType)
+}
+
+// 绑定请求头，使用 header 标签
+type Header[T any] struct {
+	Value T
+}
+
+func (param *Header[T]) bindMappingSource(boot *GobootApplication, c *gin.Context) error {
+	return boot.bindMappingValues(c, &param.Value, mappingHeaderValues(c), "header")
+}
+
+// 绑定Cookie，使用 cookie 标签
+type Cookie[T any] struct {
+	Value T
+}
+
+func (param *Cookie[T]) bindMappingSource(boot *GobootApplication, c *gin.Context) error {
+	return boot.bindMappingValues(c, &param.Value, mappingCookieValues(c), "cookie")
+}
+
+// 绑定查询参数，使用 form 标签
+type Query[T any] struct {
+	Value T
+}
+
+func (param *Query[T]) bindMappingSource(boot *GobootApplication, c *gin.Context) error {
+	return boot.bindMappingValues(c, &param.Value, c.Request.URL.Query(), "form")
+}
+
+// 绑定表单参数，不包含查询参数，使用 form 标签，multipart 表单可以绑定上传文件
+type Form[T any] struct {
+	Value T
+}
+
+func (param *Form[T]) bindMappingSource(boot *GobootApplication, c *gin.Context) error {
+	initBindingValidator()
+	var err error
+	if strings.HasPrefix(c.ContentType(), binding.MIMEMultipartPOSTForm) {
+		err = binding.FormMultipart.Bind(c.Request, &param.Value)
+	} else {
+		err = binding.FormPost.Bind(c.Request, &param.Value)
+	}
+	return boot.validateMappingParam(c, &param.Value, err)
+}
+
+// 获取请求头，标签可以使用规范格式或者小写，例如 X-Token 或 x-token
+func mappingHeaderValues(c *gin.Context) map[string][]string {
+	values := map[string][]string{}
+	for key, val := range c.Request.Header {
+		values[key] = val
+		values[strings.ToLower(key)] = val
+	}
+	return values
+}
+
+// 获取请求的Cookie
+func mappingCookieValues(c *gin.Context) map[string][]string {
+	values := map[string][]string{}
+	for _, item := range c.Request.Cookies() {
+		values[item.Name] = append(values[item.Name], item.Value)
+	}
+	return values
+}
+
+// 使用标签绑定并校验参数，bindParam 为结构体指针
+func (boot *GobootApplication) bindMappingValues(c *gin.Context, bindParam interface{}, values map[string][]string, tag string) error {
+	initBindingValidator()
+	err := binding.MapFormWithTag(bindParam, values, tag)
+	if err == nil && binding.Validator != nil {
+		err = binding.Validator.ValidateStruct(bindParam)
+	}
+	return boot.validateMappingParam(c, bindParam, err)
+}
+
+// 绑定成功后调用结构体的 Validate()，失败时返回 *BindError
+func (boot *GobootApplication) validateMappingParam(c *gin.Context, bindParam interface{}, err error) error {
+	if err == nil {
+		if item, ok := bindParam.(MappingValidator); ok {
+			err = item.Validate()
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	return boot.newBindError(c, err)
+}
+
+// 结构体标签的缓存键
+type mappingStructTag struct {
+	structType reflect.Type
+	tag        string
+}
+
+// 结构体是否有使用指定标签的字段，包含嵌套的结构体
+var mappingStructTags sync.Map
+
+func structHasTag(structType reflect.Type, tag string) bool {
+	for structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return false
+	}
+	key := mappingStructTag{structType, tag}
+	if val, ok := mappingStructTags.Load(key); ok {
+		return val.(bool)
+	}
+	ret := structFieldsHasTag(structType, tag, map[reflect.Type]bool{})
+	mappingStructTags.Store(key, ret)
+	return ret
+}
+
+func structFieldsHasTag(structType reflect.Type, tag string, visited map[reflect.Type]bool) bool {
+	if visited[structType] {
+		return false
+	}
+	visited[structType] = true
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && structFieldsHasTag(fieldType, tag, visited) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package goboot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type injectTestParam struct {
+	Token string `header:"X-Token"`
+	Lang  string `cookie:"lang"`
+	Page  int    `form:"page"`
+}
+
+type injectTestHeader struct {
+	Token string `header:"x-token" binding:"required"`
+}
+
+type injectTestQuery struct {
+	Page int `form:"page"`
+}
+
+type injectTestUser struct {
+	Name string
+}
+
+type injectTestApi struct{}
+
+func (api *injectTestApi) Param(param *injectTestParam) string {
+	return fmt.Sprintf("%v %v %v", param.Token, param.Lang, param.Page)
+}
+
+func (api *injectTestApi) Source(header Header[injectTestHeader], query *Query[injectTestQuery]) string {
+	return fmt.Sprintf("%v %v", header.Value.Token, query.Value.Page)
+}
+
+func (api *injectTestApi) Builtin(ctx context.Context, values url.Values, req *http.Request, c *gin.Context) string {
+	return fmt.Sprintf("%v %v %v", ctx == req.Context(), values.Get("page"), c.Request == req)
+}
+
+func (api *injectTestApi) Session(session sessions.Session) string {
+	session.Set("k", "v")
+	return fmt.Sprintf("%v %v", session.Get("k"), session.Save())
+}
+
+func (api *injectTestApi) User(user *injectTestUser) string {
+	return "user " + user.Name
+}
+
+func serveInjectTest(boot *GobootApplication, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for key, val := range header {
+		req.Header[key] = val
+	}
+	rec := httptest.NewRecorder()
+	boot.App.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMappingArgInject(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.Mapping.Enable = true
+	config.Goboot.Server.Mapping.Items = []string{"/api/"}
+	boot := GetConfigApplication(config, nil)
+	RegisterArgInjector(boot, func(c *gin.Context) (*injectTestUser, error) {
+		name := c.GetHeader("X-User")
+		if name == "" {
+			return nil, NewStatusError(401, "not login")
+		}
+		return &injectTestUser{Name: name}, nil
+	})
+	boot.AddHandlers(&injectTestApi{})
+	boot.compileMappings()
+
+	header := http.Header{"X-Token": {"t1"}, "Cookie": {"lang=zh"}, "X-User": {"tom"}}
+	cases := map[string]string{
+		"/api/param?page=2":   "t1 zh 2",
+		"/api/source?page=3":  "t1 3",
+		"/api/builtin?page=4": "true 4 true",
+		"/api/session":        "v session not enabled, set goboot.server.session.enable",
+		"/api/user":           "user tom",
+	}
+	for target, want := range cases {
+		rec := serveInjectTest(boot, target, header)
+		if rec.Code != 200 || rec.Body.String() != want {
+			t.Errorf("%v = %v %v, want %v", target, rec.Code, rec.Body.String(), want)
+		}
+	}
+
+	if rec := serveInjectTest(boot, "/api/source", nil); rec.Code != 400 {
+		t.Errorf("missing required header status = %v", rec.Code)
+	}
+	if rec := serveInjectTest(boot, "/api/user", nil); rec.Code != 401 {
+		t.Errorf("injector error status = %v", rec.Code)
+	}
+}
+
+func TestIsMappingSourceArg(t *testing.T) {
+	cases := map[reflect.Type]bool{
+		reflect.TypeOf(Header[injectTestHeader]{}): true,
+		reflect.TypeOf(&Cookie[injectTestParam]{}): true,
+		reflect.TypeOf(Form[injectTestQuery]{}):    true,
+		reflect.TypeOf(injectTestParam{}):          false,
+		reflect.TypeOf(&injectTestParam{}):         false,
+	}
+	for arg, want := range cases {
+		if got := isMappingSourceArg(arg); got != want {
+			t.Errorf("isMappingSourceArg(%v) = %v, want %v", arg, got, want)
+		}
+	}
+	if !structHasTag(reflect.TypeOf(&injectTestParam{}), "cookie") || structHasTag(reflect.TypeOf(injectTestQuery{}), "header") {
+		t.Errorf("structHasTag result error")
+	}
+}
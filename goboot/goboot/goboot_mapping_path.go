@@ -0,0 +1,172 @@
+package goboot
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 映射路由模板区
+// 处理器和控制器实现 Routes() 时，可以为函数指定REST风格的路由模板
+// 键为路由模板，值为函数名，路径相对于mapping前缀或者controller路径
+//
+// func (api *Api) Routes() map[string]string {
+// 	return map[string]string{
+// 		"GET /user/:id/orders": "User_Orders",
+// 		"/user/:id":            "XD_User",
+// 	}
+// }
+//
+// 模板中的请求方式可以省略，省略时使用函数名前缀的请求方式
+// 使用模板的函数只注册模板的路由，不再注册函数名转换的路径
+//
+// 路径变量的注入：
+// 结构体参数使用 uri 标签，例如 Id int `uri:"id"`
+// 普通类型的参数 string, int, uint, float, bool，按照顺序注入路径变量
+// func (api *Api) User_Orders(id int64, query *OrderQuery) any
+// /////////////////////////////////////////////////////////
+
+// 提供路由模板的处理器
+type MappingRouter interface {
+	Routes() map[string]string
+}
+
+// 路由模板
+type mappingTemplate struct {
+	Method string // 为空时使用函数名前缀的请求方式
+	Path   string // 相对路径，不以 / 开头
+}
+
+// 模板中可用的请求方式
+var mappingTemplateMethods = map[string]bool{
+	"GET":     true,
+	"POST":    true,
+	"PUT":     true,
+	"DELETE":  true,
+	"PATCH":   true,
+	"HEAD":    true,
+	"OPTIONS": true,
+	"ANY":     true,
+}
+
+// 解析处理器的路由模板，返回函数名到模板的映射
+func parseMappingTemplates(handler interface{}) (map[string][]mappingTemplate, error) {
+	ret := map[string][]mappingTemplate{}
+	router, ok := handler.(MappingRouter)
+	if !ok {
+		return ret, nil
+	}
+	routes := router.Routes()
+	keys := []string{}
+	for key := range routes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		item := mappingTemplate{}
+		fields := strings.Fields(key)
+		switch len(fields) {
+		case 1:
+			item.Path = fields[0]
+		case 2:
+			item.Method = strings.ToUpper(fields[0])
+			item.Path = fields[1]
+			if !mappingTemplateMethods[item.Method] {
+				return ret, fmt.Errorf("route %v invalid method %v", key, fields[0])
+			}
+		default:
+			return ret, fmt.Errorf("route %v require format [METHOD] /path", key)
+		}
+		item.Path = strings.TrimPrefix(item.Path, "/")
+		funcName := strings.TrimSpace(routes[key])
+		if funcName == "" {
+			return ret, fmt.Errorf("route %v require function name", key)
+		}
+		ret[funcName] = append(ret[funcName], item)
+	}
+	return ret, nil
+}
+
+// 获取路径中的变量名称，例如 user/:id/files/*path --> id, path
+func mappingPathParams(urlPath string) []string {
+	ret := []string{}
+	for _, item := range strings.Split(urlPath, "/") {
+		if strings.HasPrefix(item, ":") || strings.HasPrefix(item, "*") {
+			ret = append(ret, item[1:])
+		}
+	}
+	return ret
+}
+
+// 是否为注入路径变量的普通类型参数
+func isMappingPathArg(arg reflect.Type) bool {
+	switch arg.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// 将字符串转换为普通类型的值
+func convertStringValue(text string, arg reflect.Type) (reflect.Value, error) {
+	val := reflect.New(arg).Elem()
+	switch arg.Kind() {
+	case reflect.String:
+		val.SetString(text)
+	case reflect.Bool:
+		ret, err := strconv.ParseBool(text)
+		if err != nil {
+			return val, err
+		}
+		val.SetBool(ret)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ret, err := strconv.ParseInt(text, 10, arg.Bits())
+		if err != nil {
+			return val, err
+		}
+		val.SetInt(ret)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		ret, err := strconv.ParseUint(text, 10, arg.Bits())
+		if err != nil {
+			return val, err
+		}
+		val.SetUint(ret)
+	case reflect.Float32, reflect.Float64:
+		ret, err := strconv.ParseFloat(text, arg.Bits())
+		if err != nil {
+			return val, err
+		}
+		val.SetFloat(ret)
+	default:
+		return val, fmt.Errorf("not support type %v", arg)
+	}
+	return val, nil
+}
+
+// 转换路径变量为参数值，转换失败时返回 *BindError
+func (boot *GobootApplication) convertPathParam(c *gin.Context, name string, arg reflect.Type) (reflect.Value, error) {
+	val, err := convertStringValue(c.Param(name), arg)
+	if err != nil {
+		locale := boot.validationLocale(c)
+		message := ValidationMessage(locale, "_type", name, arg.String())
+		return val, &BindError{
+			Msg: ValidationMessage(locale, "_invalid", "", "") + ": " + message,
+			Fields: []FieldError{{
+				Field:   name,
+				Tag:     "type",
+				Param:   arg.String(),
+				Message: message,
+			}},
+			Err: err,
+		}
+	}
+	return val, nil
+}
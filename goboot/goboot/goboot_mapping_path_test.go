@@ -0,0 +1,118 @@
+package goboot
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type pathTestQuery struct {
+	Id   int64  `uri:"id"`
+	Page int    `form:"page"`
+	Path string `uri:"path"`
+}
+
+type pathTestApi struct{}
+
+func (api *pathTestApi) Routes() map[string]string {
+	return map[string]string{
+		"GET /user/:id/orders": "User_Orders",
+		"/user/:id":            "XD_User",
+		"GET /files/*path":     "Files",
+	}
+}
+
+func (api *pathTestApi) User_Orders(id int64, query *pathTestQuery) string {
+	return fmt.Sprintf("orders %v %v %v", id, query.Id, query.Page)
+}
+
+func (api *pathTestApi) XD_User(id uint) string {
+	return fmt.Sprintf("delete %v", id)
+}
+
+func (api *pathTestApi) Files(query *pathTestQuery) string {
+	return "file " + query.Path
+}
+
+type pathBadRouteApi struct{}
+
+func (api *pathBadRouteApi) Routes() map[string]string {
+	return map[string]string{"FETCH /user": "User"}
+}
+
+func (api *pathBadRouteApi) User() string { return "" }
+
+func TestMappingPathTemplates(t *testing.T) {
+	boot := newMappingTestApp(t, &pathTestApi{})
+	cases := []struct {
+		method string
+		target string
+		code   int
+		body   string
+	}{
+		{"GET", "/api/user/12/orders?page=3", 200, "orders 12 12 3"},
+		{"DELETE", "/api/user/7", 200, "delete 7"},
+		{"GET", "/api/files/a/b.txt", 200, "file /a/b.txt"},
+		{"POST", "/api/user/7", 405, ""},
+		// 使用模板的函数不再注册函数名转换的路径
+		{"GET", "/api/User_Orders", 404, ""},
+	}
+	for _, item := range cases {
+		rec := serveMappingTest(boot, item.method, item.target)
+		if rec.Code != item.code || (item.body != "" && rec.Body.String() != item.body) {
+			t.Errorf("%v %v = %v %v, want %v %v", item.method, item.target, rec.Code, rec.Body.String(), item.code, item.body)
+		}
+	}
+
+	rec := serveMappingTest(boot, "DELETE", "/api/user/abc")
+	if rec.Code != 400 {
+		t.Fatalf("invalid path param status = %v, body %v", rec.Code, rec.Body.String())
+	}
+}
+
+func TestParseMappingTemplatesError(t *testing.T) {
+	if _, err := parseMappingTemplates(&pathBadRouteApi{}); err == nil {
+		t.Fatalf("expected error for invalid method")
+	}
+	templates, err := parseMappingTemplates(&pathTestApi{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got := templates["XD_User"]; !reflect.DeepEqual(got, []mappingTemplate{{Path: "user/:id"}}) {
+		t.Fatalf("XD_User templates = %v", got)
+	}
+}
+
+func TestMappingPathParams(t *testing.T) {
+	if got := mappingPathParams("user/:id/files/*path"); !reflect.DeepEqual(got, []string{"id", "path"}) {
+		t.Fatalf("params = %v", got)
+	}
+}
+
+func TestConvertStringValue(t *testing.T) {
+	cases := []struct {
+		text string
+		kind interface{}
+		want interface{}
+	}{
+		{"12", int8(0), int8(12)},
+		{"12", uint(0), uint(12)},
+		{"1.5", float64(0), 1.5},
+		{"true", false, true},
+		{"abc", "", "abc"},
+	}
+	for _, item := range cases {
+		val, err := convertStringValue(item.text, reflect.TypeOf(item.kind))
+		if err != nil || val.Interface() != item.want {
+			t.Errorf("convert %v to %T = %v, %v", item.text, item.kind, val, err)
+		}
+	}
+	for _, item := range []interface{}{int8(0), uint(0), false} {
+		if _, err := convertStringValue("300x", reflect.TypeOf(item)); err == nil {
+			t.Errorf("expected error converting to %T", item)
+		}
+	}
+	if _, err := convertStringValue("-1", reflect.TypeOf(uint(0))); err == nil {
+		t.Errorf("expected error converting -1 to uint")
+	}
+}
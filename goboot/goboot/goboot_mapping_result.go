@@ -0,0 +1,112 @@
+package goboot
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 映射返回值区
+// 映射函数的返回值自动写入响应，函数已经写入响应时不再处理
+// *ApiResp 响应JSON
+// string 响应文本
+// []byte, io.Reader 响应数据流，未设置 Content-Type 时为 application/octet-stream
+// error 不为nil时交给错误处理器链，默认响应 ApiError，HTTP状态码使用错误的 StatusCode()，默认500
+// *CtxResp 以及 nil 指针不响应
+// 其他类型，例如结构体、map、切片，响应JSON，开启 goboot.server.mapping.wrapApiResp 时使用 ApiOk 包装
+// /////////////////////////////////////////////////////////
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// 处理映射函数的返回值
+func (boot *GobootApplication) handleMappingResult(c *gin.Context, route *MappingRoute, results []reflect.Value) {
+	var value reflect.Value
+	var err error
+	for _, item := range results {
+		if item.Type().Implements(errorType) {
+			if err == nil && !isNilValue(item) {
+				err = item.Interface().(error)
+			}
+			continue
+		}
+		if !value.IsValid() {
+			value = item
+		}
+	}
+
+	if err != nil {
+		if c.Writer.Written() {
+			LogError("goboot mapping %v error after response written: %v", route.HandlerName, err)
+			return
+		}
+		boot.HandleError(c, err)
+		return
+	}
+	if !value.IsValid() || c.Writer.Written() {
+		return
+	}
+	WriteMappingResult(c, value.Interface(), boot.CurrentConfig().Goboot.Server.Mapping.WrapApiResp)
+}
+
+// 是否为nil值
+func isNilValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return val.IsNil()
+	}
+	return false
+}
+
+// 将返回值写入响应
+// 状态码使用当前设置的状态码，映射函数调用前为200，可以在函数或拦截器中使用 c.Status 修改
+// wrap 为true时，JSON数据使用 ApiOk 包装
+func WriteMappingResult(c *gin.Context, result interface{}, wrap bool) {
+	status := c.Writer.Status()
+	switch val := result.(type) {
+	case nil:
+		if wrap {
+			c.JSON(status, ApiOk(nil))
+		}
+	case *CtxResp, CtxResp:
+		// 已经通过 CtxResp 响应
+	case *ApiResp:
+		if val != nil {
+			c.JSON(status, val.WithRequestId(c))
+		}
+	case ApiResp:
+		c.JSON(status, val.WithRequestId(c))
+	case string:
+		c.String(status, val)
+	case []byte:
+		c.Data(status, mappingResultContentType(c), val)
+	case io.Reader:
+		if closer, ok := val.(io.Closer); ok {
+			defer closer.Close()
+		}
+		c.DataFromReader(status, -1, mappingResultContentType(c), val, nil)
+	default:
+		rval := reflect.ValueOf(result)
+		if rval.Kind() == reflect.Ptr && rval.IsNil() {
+			if wrap {
+				c.JSON(status, ApiOk(nil))
+			}
+			return
+		}
+		if wrap {
+			c.JSON(status, ApiOk(result))
+			return
+		}
+		c.JSON(status, result)
+	}
+}
+
+// 数据响应的类型，未设置时为 application/octet-stream
+func mappingResultContentType(c *gin.Context) string {
+	contentType := c.Writer.Header().Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return contentType
+}
@@ -0,0 +1,78 @@
+package goboot
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type resultTestApi struct{}
+
+type resultTestUser struct {
+	Name string `json:"name"`
+}
+
+func (api *resultTestApi) Text() string { return "hello" }
+func (api *resultTestApi) Bytes() []byte {
+	return []byte{1, 2}
+}
+func (api *resultTestApi) Reader() *bytes.Reader {
+	return bytes.NewReader([]byte("stream"))
+}
+func (api *resultTestApi) User() (*resultTestUser, error) {
+	return &resultTestUser{Name: "tom"}, nil
+}
+func (api *resultTestApi) NilUser() *resultTestUser { return nil }
+func (api *resultTestApi) Fail() (*resultTestUser, error) {
+	return nil, NewStatusError(409, "conflict")
+}
+func (api *resultTestApi) Panic() error { return errors.New("boom") }
+func (api *resultTestApi) Created(c *gin.Context) *ApiResp {
+	c.Status(201)
+	return ApiOk("created")
+}
+
+func TestMappingResult(t *testing.T) {
+	boot := newMappingTestApp(t, &resultTestApi{})
+	cases := []struct {
+		target      string
+		code        int
+		body        string
+		contentType string
+	}{
+		{"/api/text", 200, "hello", "text/plain; charset=utf-8"},
+		{"/api/bytes", 200, "\x01\x02", "application/octet-stream"},
+		{"/api/reader", 200, "stream", "application/octet-stream"},
+		{"/api/user", 200, `{"name":"tom"}`, "application/json; charset=utf-8"},
+		{"/api/nil-user", 200, "", ""},
+		{"/api/fail", 409, `"msg":"conflict"`, "application/json; charset=utf-8"},
+		{"/api/panic", 500, `"msg":"boom"`, "application/json; charset=utf-8"},
+		{"/api/created", 201, `"data":"created"`, "application/json; charset=utf-8"},
+		// 按函数名匹配的路径在 NoRoute 中处理，状态码不能沿用404
+		{"/api/Text", 200, "hello", "text/plain; charset=utf-8"},
+		{"/api/User", 200, `{"name":"tom"}`, "application/json; charset=utf-8"},
+		{"/api/Created", 201, `"data":"created"`, "application/json; charset=utf-8"},
+	}
+	for _, item := range cases {
+		rec := serveMappingTest(boot, "GET", item.target)
+		if rec.Code != item.code || !bytes.Contains(rec.Body.Bytes(), []byte(item.body)) {
+			t.Errorf("%v = %v %q, want %v %q", item.target, rec.Code, rec.Body.String(), item.code, item.body)
+		}
+		if got := rec.Header().Get("Content-Type"); got != item.contentType {
+			t.Errorf("%v content type = %v, want %v", item.target, got, item.contentType)
+		}
+	}
+}
+
+func TestWriteMappingResultWrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	WriteMappingResult(c, map[string]int{"a": 1}, true)
+	if rec.Code != 200 || rec.Body.String() != `{"code":200,"msg":"","data":{"a":1}}` {
+		t.Fatalf("response = %v %v", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,103 @@
+package goboot
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type mappingTestApi struct{}
+
+func (api *mappingTestApi) XG_User_Info() string         { return "info" }
+func (api *mappingTestApi) XP_User_Info() string         { return "save" }
+func (api *mappingTestApi) FindUser_GeoRange() string    { return "geo" }
+func (api *mappingTestApi) Skip(id int, name int) string { return "" }
+
+type mappingTestDuplicate struct{}
+
+func (api *mappingTestDuplicate) User_Info() string { return "" }
+
+func TestMappingMethodNameAndPath(t *testing.T) {
+	cases := map[string]string{
+		"user":                "User",
+		"user/info":           "User_Info",
+		"/user/fav-icon/":     "User_FavIcon",
+		"find-user/geo-range": "FindUser_GeoRange",
+	}
+	for urlPath, want := range cases {
+		if got := MappingMethodName(urlPath); got != want {
+			t.Errorf("MappingMethodName(%v) = %v, want %v", urlPath, got, want)
+		}
+	}
+	if got := MappingMethodPath("FindUser_GeoRange"); got != "find-user/geo-range" {
+		t.Errorf("MappingMethodPath = %v", got)
+	}
+	method, name := ParseMappingFuncName("XG_User_Info")
+	if method != "GET" || name != "User_Info" {
+		t.Errorf("ParseMappingFuncName = %v %v", method, name)
+	}
+	if method, _ := ParseMappingFuncName("User_Info"); method != MappingMethodAny {
+		t.Errorf("method without prefix = %v", method)
+	}
+}
+
+func newMappingTestApp(t *testing.T, handlers ...interface{}) *GobootApplication {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.Mapping.Enable = true
+	config.Goboot.Server.Mapping.Items = []string{"/api/"}
+	boot := GetConfigApplication(config, nil)
+	boot.AddHandlers(handlers...)
+	boot.compileMappings()
+	boot.enableMethodNotAllowed()
+	return boot
+}
+
+func serveMappingTest(boot *GobootApplication, method string, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	boot.App.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestCompileMappings(t *testing.T) {
+	boot := newMappingTestApp(t, &mappingTestApi{})
+	if len(boot.startupErrors) > 0 {
+		t.Fatalf("startup errors: %v", boot.startupErrors)
+	}
+	paths := []string{}
+	for _, route := range boot.MappingRoutes() {
+		paths = append(paths, route.Method+" "+route.Path)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("routes = %v, int arguments should be skipped", paths)
+	}
+
+	cases := []struct {
+		method string
+		target string
+		code   int
+		body   string
+	}{
+		{"GET", "/api/user/info", 200, "info"},
+		{"POST", "/api/user/info", 200, "save"},
+		{"GET", "/api/find-user/geo-range", 200, "geo"},
+		{"GET", "/api/FindUser_GeoRange", 200, "geo"},
+		{"GET", "/api/findUser/geoRange", 200, "geo"},
+		{"DELETE", "/api/User_Info", 405, ""},
+		{"GET", "/api/not-exists", 404, ""},
+	}
+	for _, item := range cases {
+		rec := serveMappingTest(boot, item.method, item.target)
+		if rec.Code != item.code || (item.body != "" && !strings.Contains(rec.Body.String(), item.body)) {
+			t.Errorf("%v %v = %v %v, want %v %v", item.method, item.target, rec.Code, rec.Body.String(), item.code, item.body)
+		}
+	}
+}
+
+func TestCompileMappingsConflict(t *testing.T) {
+	boot := newMappingTestApp(t, &mappingTestApi{}, &mappingTestDuplicate{})
+	if len(boot.startupErrors) != 1 || !strings.Contains(boot.startupErrors[0], "ambiguous mapping /api/user/info") {
+		t.Fatalf("startup errors = %v", boot.startupErrors)
+	}
+}
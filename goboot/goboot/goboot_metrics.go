@@ -0,0 +1,438 @@
+package goboot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 指标区
+// 开启 goboot.server.metrics.enable 后，在 path(默认 /metrics) 提供 Prometheus 文本格式的指标
+// 管理端点使用单独端口时，指标也在管理端口提供
+// 指标与管理端点使用相同的令牌 goboot.server.management.token，开启 public 时不需要令牌
+//
+// 内置指标：
+// goboot_http_requests_total, goboot_http_request_duration_seconds 按路由模板或自动映射的函数名统计
+// goboot_proxy_upstream_duration_seconds, goboot_proxy_upstream_errors_total 按代理名称统计
+// goboot_file_server_bytes_total 文件服务器下载和上传的字节数
+// goboot_db_* 数据源连接池，goboot_redis_pool_* redis连接池，go_* 运行时
+//
+// 自定义指标使用 boot.Metrics 注册
+// counter := boot.Metrics.Counter("myapp_orders_total", "orders created", "type")
+// counter.With("vip").Inc()
+// /////////////////////////////////////////////////////////
+
+// 默认指标路径
+const DefaultMetricsPath string = "/metrics"
+
+// 默认耗时直方图的桶，单位秒
+var DefaultMetricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// gin上下文中保存的键
+const (
+	// 自动映射匹配到的函数名，用于指标的路由标签
+	ContextMappingMethodKey string = "goboot.mappingMethod"
+	// 代理名称
+	ContextProxyNameKey string = "goboot.proxyName"
+	// 当前应用的内置指标
+	contextMetricsKey string = "goboot.metrics"
+)
+
+// 指标配置
+type Metrics struct {
+	Enable  bool      `yaml:"enable"`
+	Path    string    `yaml:"path"`    // 指标路径，默认 /metrics
+	Public  bool      `yaml:"public"`  // 是否不需要令牌
+	Buckets []float64 `yaml:"buckets"` // 耗时直方图的桶，单位秒
+}
+
+// 指标类型
+const (
+	MetricCounter   string = "counter"
+	MetricGauge     string = "gauge"
+	MetricHistogram string = "histogram"
+)
+
+// 指标注册表
+type MetricsRegistry struct {
+	lock       sync.RWMutex
+	metrics    []*MetricVec
+	names      map[string]*MetricVec
+	collectors []func()
+}
+
+// 一组同名不同标签的指标
+type MetricVec struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	lock    sync.Mutex
+	series  map[string]*Metric
+}
+
+// 一个标签组合的指标值
+type Metric struct {
+	vec         *MetricVec
+	labelValues []string
+	lock        sync.Mutex
+	value       float64
+	// 直方图使用
+	counts []uint64
+	count  uint64
+}
+
+// 创建指标注册表
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		names: map[string]*MetricVec{},
+	}
+}
+
+// 注册指标，同名同类型时返回已注册的指标
+func (registry *MetricsRegistry) register(name string, help string, kind string, buckets []float64, labels []string) *MetricVec {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	if vec, ok := registry.names[name]; ok {
+		if vec.kind != kind {
+			panic(fmt.Sprintf("metric %v already registered as %v", name, vec.kind))
+		}
+		return vec
+	}
+	vec := &MetricVec{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  map[string]*Metric{},
+	}
+	registry.metrics = append(registry.metrics, vec)
+	registry.names[name] = vec
+	return vec
+}
+
+// 注册计数器
+func (registry *MetricsRegistry) Counter(name string, help string, labels ...string) *MetricVec {
+	return registry.register(name, help, MetricCounter, nil, labels)
+}
+
+// 注册仪表
+func (registry *MetricsRegistry) Gauge(name string, help string, labels ...string) *MetricVec {
+	return registry.register(name, help, MetricGauge, nil, labels)
+}
+
+// 注册直方图，buckets 为空时使用默认的桶
+func (registry *MetricsRegistry) Histogram(name string, help string, buckets []float64, labels ...string) *MetricVec {
+	if len(buckets) == 0 {
+		buckets = DefaultMetricsBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	return registry.register(name, help, MetricHistogram, buckets, labels)
+}
+
+// 添加采集函数，每次输出指标前调用，用于更新连接池等仪表
+func (registry *MetricsRegistry) OnCollect(collector func()) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.collectors = append(registry.collectors, collector)
+}
+
+// 获取指定标签值的指标，标签值的个数必须与注册时一致
+func (vec *MetricVec) With(labelValues ...string) *Metric {
+	if len(labelValues) != len(vec.labels) {
+		panic(fmt.Sprintf("metric %v require %v label(s), but got %v", vec.name, len(vec.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	vec.lock.Lock()
+	defer vec.lock.Unlock()
+	metric, ok := vec.series[key]
+	if !ok {
+		metric = &Metric{
+			vec:         vec,
+			labelValues: append([]string{}, labelValues...),
+		}
+		if vec.kind == MetricHistogram {
+			metric.counts = make([]uint64, len(vec.buckets))
+		}
+		vec.series[key] = metric
+	}
+	return metric
+}
+
+// 计数加1
+func (metric *Metric) Inc() {
+	metric.Add(1)
+}
+
+// 增加值
+func (metric *Metric) Add(val float64) {
+	metric.lock.Lock()
+	defer metric.lock.Unlock()
+	metric.value += val
+}
+
+// 设置值，用于仪表
+func (metric *Metric) Set(val float64) {
+	metric.lock.Lock()
+	defer metric.lock.Unlock()
+	metric.value = val
+}
+
+// 记录一次观测值，用于直方图
+func (metric *Metric) Observe(val float64) {
+	metric.lock.Lock()
+	defer metric.lock.Unlock()
+	for i, bucket := range metric.vec.buckets {
+		if val <= bucket {
+			metric.counts[i]++
+		}
+	}
+	metric.count++
+	metric.value += val
+}
+
+// 以 Prometheus 文本格式输出所有指标
+func (registry *MetricsRegistry) WriteText(writer io.Writer) error {
+	registry.lock.RLock()
+	collectors := append([]func(){}, registry.collectors...)
+	metrics := append([]*MetricVec{}, registry.metrics...)
+	registry.lock.RUnlock()
+
+	for _, collector := range collectors {
+		collector()
+	}
+
+	buf := bufio.NewWriter(writer)
+	for _, vec := range metrics {
+		vec.lock.Lock()
+		keys := make([]string, 0, len(vec.series))
+		for key := range vec.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		series := make([]*Metric, 0, len(keys))
+		for _, key := range keys {
+			series = append(series, vec.series[key])
+		}
+		vec.lock.Unlock()
+		if len(series) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(buf, "# HELP %v %v\n", vec.name, escapeMetricHelp(vec.help))
+		fmt.Fprintf(buf, "# TYPE %v %v\n", vec.name, vec.kind)
+		for _, metric := range series {
+			metric.lock.Lock()
+			if vec.kind == MetricHistogram {
+				for i, bucket := range vec.buckets {
+					fmt.Fprintf(buf, "%v_bucket%v %v\n", vec.name, formatMetricLabels(vec.labels, metric.labelValues, "le", formatMetricValue(bucket)), metric.counts[i])
+				}
+				fmt.Fprintf(buf, "%v_bucket%v %v\n", vec.name, formatMetricLabels(vec.labels, metric.labelValues, "le", "+Inf"), metric.count)
+				fmt.Fprintf(buf, "%v_sum%v %v\n", vec.name, formatMetricLabels(vec.labels, metric.labelValues, "", ""), formatMetricValue(metric.value))
+				fmt.Fprintf(buf, "%v_count%v %v\n", vec.name, formatMetricLabels(vec.labels, metric.labelValues, "", ""), metric.count)
+			} else {
+				fmt.Fprintf(buf, "%v%v %v\n", vec.name, formatMetricLabels(vec.labels, metric.labelValues, "", ""), formatMetricValue(metric.value))
+			}
+			metric.lock.Unlock()
+		}
+	}
+	return buf.Flush()
+}
+
+// 格式化标签，extraName 不为空时追加一个标签
+func formatMetricLabels(names []string, values []string, extraName string, extraValue string) string {
+	items := []string{}
+	for i, name := range names {
+		items = append(items, fmt.Sprintf(`%v="%v"`, name, escapeMetricLabel(values[i])))
+	}
+	if extraName != "" {
+		items = append(items, fmt.Sprintf(`%v="%v"`, extraName, extraValue))
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(items, ",") + "}"
+}
+
+// 格式化指标值
+func formatMetricValue(val float64) string {
+	if math.IsInf(val, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(val, 'g', -1, 64)
+}
+
+func escapeMetricLabel(str string) string {
+	str = strings.ReplaceAll(str, `\`, `\\`)
+	str = strings.ReplaceAll(str, `"`, `\"`)
+	return strings.ReplaceAll(str, "\n", `\n`)
+}
+
+func escapeMetricHelp(str string) string {
+	str = strings.ReplaceAll(str, `\`, `\\`)
+	return strings.ReplaceAll(str, "\n", `\n`)
+}
+
+// goboot 内置指标
+type gobootMetrics struct {
+	httpRequests    *MetricVec
+	httpDuration    *MetricVec
+	proxyDuration   *MetricVec
+	proxyErrors     *MetricVec
+	fileServerBytes *MetricVec
+}
+
+// 注册内置指标
+func (boot *GobootApplication) registerBuiltinMetrics(config Metrics) {
+	registry := boot.Metrics
+	boot.metrics = &gobootMetrics{
+		httpRequests:    registry.Counter("goboot_http_requests_total", "Total number of HTTP requests.", "method", "route", "status"),
+		httpDuration:    registry.Histogram("goboot_http_request_duration_seconds", "HTTP request latency in seconds.", config.Buckets, "method", "route"),
+		proxyDuration:   registry.Histogram("goboot_proxy_upstream_duration_seconds", "Proxy upstream latency in seconds.", config.Buckets, "proxy"),
+		proxyErrors:     registry.Counter("goboot_proxy_upstream_errors_total", "Total number of proxy upstream errors.", "proxy"),
+		fileServerBytes: registry.Counter("goboot_file_server_bytes_total", "Total bytes served or uploaded by file server.", "direction"),
+	}
+
+	goroutines := registry.Gauge("go_goroutines", "Number of goroutines that currently exist.")
+	heapAlloc := registry.Gauge("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use.")
+	dbOpen := registry.Gauge("goboot_db_open_connections", "The number of established connections both in use and idle.")
+	dbInUse := registry.Gauge("goboot_db_in_use_connections", "The number of connections currently in use.")
+	dbIdle := registry.Gauge("goboot_db_idle_connections", "The number of idle connections.")
+	dbMaxOpen := registry.Gauge("goboot_db_max_open_connections", "Maximum number of open connections to the database.")
+	dbWaitCount := registry.Gauge("goboot_db_wait_count", "The total number of connections waited for.")
+	dbWaitDuration := registry.Gauge("goboot_db_wait_duration_seconds", "The total time blocked waiting for a new connection.")
+	redisHits := registry.Gauge("goboot_redis_pool_hits", "Number of times free connection was found in the pool.")
+	redisMisses := registry.Gauge("goboot_redis_pool_misses", "Number of times free connection was NOT found in the pool.")
+	redisTimeouts := registry.Gauge("goboot_redis_pool_timeouts", "Number of times a wait timeout occurred.")
+	redisTotal := registry.Gauge("goboot_redis_pool_total_connections", "Number of total connections in the pool.")
+	redisIdle := registry.Gauge("goboot_redis_pool_idle_connections", "Number of idle connections in the pool.")
+
+	registry.OnCollect(func() {
+		goroutines.With().Set(float64(runtime.NumGoroutine()))
+		mem := runtime.MemStats{}
+		runtime.ReadMemStats(&mem)
+		heapAlloc.With().Set(float64(mem.HeapAlloc))
+
+		if boot.Db != nil {
+			stats := boot.Db.Stats()
+			dbOpen.With().Set(float64(stats.OpenConnections))
+			dbInUse.With().Set(float64(stats.InUse))
+			dbIdle.With().Set(float64(stats.Idle))
+			dbMaxOpen.With().Set(float64(stats.MaxOpenConnections))
+			dbWaitCount.With().Set(float64(stats.WaitCount))
+			dbWaitDuration.With().Set(stats.WaitDuration.Seconds())
+		}
+		if boot.Redis != nil && boot.Redis.Redis != nil {
+			stats := boot.Redis.Redis.PoolStats()
+			redisHits.With().Set(float64(stats.Hits))
+			redisMisses.With().Set(float64(stats.Misses))
+			redisTimeouts.With().Set(float64(stats.Timeouts))
+			redisTotal.With().Set(float64(stats.TotalConns))
+			redisIdle.With().Set(float64(stats.IdleConns))
+		}
+	})
+}
+
+// 获取当前请求的内置指标，未开启指标时为nil
+func metricsFromContext(c *gin.Context) *gobootMetrics {
+	val, ok := c.Get(contextMetricsKey)
+	if !ok {
+		return nil
+	}
+	return val.(*gobootMetrics)
+}
+
+// 请求指标中间件
+func (boot *GobootApplication) metricsMiddleware() gin.HandlerFunc {
+	metrics := boot.metrics
+	return func(c *gin.Context) {
+		c.Set(contextMetricsKey, metrics)
+		start := time.Now()
+		c.Next()
+
+		// 路由标签使用路由模板，避免路径参数导致标签过多
+		route := c.FullPath()
+		if route == "" {
+			if method := c.GetString(ContextMappingMethodKey); method != "" {
+				route = "mapping:" + method
+			} else if name := c.GetString(ContextProxyNameKey); name != "" {
+				route = "proxy:" + name
+			} else {
+				route = "unmatched"
+			}
+		}
+		method := c.Request.Method
+		metrics.httpRequests.With(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.httpDuration.With(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// 记录代理上游的耗时和错误
+func recordProxyMetrics(c *gin.Context, start time.Time, failed bool) {
+	metrics := metricsFromContext(c)
+	if metrics == nil {
+		return
+	}
+	name := c.GetString(ContextProxyNameKey)
+	metrics.proxyDuration.With(name).Observe(time.Since(start).Seconds())
+	if failed {
+		metrics.proxyErrors.With(name).Inc()
+	}
+}
+
+// 记录文件服务器的字节数，direction 为 download 或者 upload
+func recordFileServerBytes(c *gin.Context, direction string, size int64) {
+	metrics := metricsFromContext(c)
+	if metrics == nil || size <= 0 {
+		return
+	}
+	metrics.fileServerBytes.With(direction).Add(float64(size))
+}
+
+// 指标输出处理函数
+func (boot *GobootApplication) metricsHandler(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	err := boot.Metrics.WriteText(c.Writer)
+	if err != nil {
+		LogWarn("goboot write metrics error of %v", err)
+	}
+}
+
+// 注册指标端点，未开启 public 时需要管理端点的令牌
+func (boot *GobootApplication) registerMetricsEndpoint(router gin.IRouter, server Server) {
+	handlers := []gin.HandlerFunc{}
+	if !server.Metrics.Public {
+		management := server.Management
+		management.PublicHealth = false
+		handlers = append(handlers, managementTokenMiddleware(management, ""))
+	}
+	handlers = append(handlers, boot.metricsHandler)
+	router.GET(metricsPath(server.Metrics), handlers...)
+}
+
+// 获取指标路径
+func metricsPath(config Metrics) string {
+	path := config.Path
+	if path == "" {
+		path = DefaultMetricsPath
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
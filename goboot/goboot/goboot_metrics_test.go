@@ -0,0 +1,80 @@
+package goboot
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsRegistryWriteText(t *testing.T) {
+	registry := NewMetricsRegistry()
+	counter := registry.Counter("test_orders_total", "orders\ncreated", "type")
+	counter.With(`v"ip`).Inc()
+	counter.With(`v"ip`).Add(2)
+	gauge := registry.Gauge("test_queue_size", "queue size")
+	registry.OnCollect(func() {
+		gauge.With().Set(5)
+	})
+	histogram := registry.Histogram("test_seconds", "latency", []float64{0.1, 1})
+	histogram.With().Observe(0.05)
+	histogram.With().Observe(0.5)
+
+	buf := &bytes.Buffer{}
+	if err := registry.WriteText(buf); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	out := buf.String()
+	for _, item := range []string{
+		"# HELP test_orders_total orders\\ncreated",
+		"# TYPE test_orders_total counter",
+		`test_orders_total{type="v\"ip"} 3`,
+		"test_queue_size 5",
+		`test_seconds_bucket{le="0.1"} 1`,
+		`test_seconds_bucket{le="1"} 2`,
+		`test_seconds_bucket{le="+Inf"} 2`,
+		"test_seconds_sum 0.55",
+		"test_seconds_count 2",
+	} {
+		if !strings.Contains(out, item) {
+			t.Errorf("output not contains %v:\n%v", item, out)
+		}
+	}
+}
+
+func TestMetricsEndpointRequireToken(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.Metrics.Enable = true
+	config.Goboot.Server.Management.Token = "mt"
+	boot := GetConfigApplication(config, nil)
+
+	serve := func(token string) int {
+		req := httptest.NewRequest("GET", DefaultMetricsPath, nil)
+		if token != "" {
+			req.Header.Set(ManagementTokenHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		boot.App.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := serve(""); code != 401 {
+		t.Fatalf("status without token = %v, want 401", code)
+	}
+	if code := serve("wrong"); code != 401 {
+		t.Fatalf("status with wrong token = %v, want 401", code)
+	}
+	if code := serve("mt"); code != 200 {
+		t.Fatalf("status with token = %v, want 200", code)
+	}
+
+	config.Goboot.Server.Management.Token = ""
+	if errs := ValidateGobootConfig(config); !containsConfigError(errs, "goboot.server.metrics") {
+		t.Fatalf("expected error for metrics without token, got %v", errs)
+	}
+	config.Goboot.Server.Metrics.Public = true
+	if errs := ValidateGobootConfig(config); len(errs) > 0 {
+		t.Fatalf("errors for public metrics: %v", errs)
+	}
+}
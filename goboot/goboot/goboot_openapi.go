@@ -0,0 +1,753 @@
+package goboot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot OpenAPI文档区
+// 开启 goboot.server.openapi.enable 后，使用 Run 时编译的路由表生成 OpenAPI 3 文档
+// GET /openapi.json  文档
+// GET /swagger-ui    重定向到文件服务嵌入的 public/viewer/swagger-ui.html
+//
+// 请求方式使用函数名前缀，XA_ 和无前缀的函数生成 get 和 post 两个操作
+// 路径变量来自路由模板，普通类型的参数和 uri 标签的字段提供类型
+// 结构体参数，GET/DELETE/HEAD 使用 form 标签生成查询参数，其他请求方式生成 json 和表单请求体
+// header/cookie/query 标签的字段，以及 Header[T] 等包装类型，生成对应位置的参数
+// 响应使用第一个不是 error 的返回值类型，开启 wrapApiResp 时使用 ApiResp 包装
+// Swagger UI 页面需要文件服务开启，并设置 EmbedStaticFs 为嵌入的 public 目录
+// /////////////////////////////////////////////////////////
+
+// 默认的文档路径
+const (
+	DefaultOpenApiPath   string = "/openapi.json"
+	DefaultOpenApiUiPath string = "/swagger-ui"
+)
+
+// 嵌入的 public 目录中 Swagger UI 页面的路径
+const openApiUiFile string = "viewer/swagger-ui.html"
+
+// OpenAPI文档配置
+type OpenApi struct {
+	Enable      bool   `yaml:"enable"`
+	Path        string `yaml:"path"`        // 文档路径，默认 /openapi.json
+	UiPath      string `yaml:"uiPath"`      // Swagger UI 路径，默认 /swagger-ui
+	Title       string `yaml:"title"`       // 标题，默认为应用名称
+	Version     string `yaml:"version"`     // 版本，默认 1.0.0
+	Description string `yaml:"description"` // 描述
+}
+
+// OpenAPI文档
+type OpenApiDoc struct {
+	OpenApi    string                                  `json:"openapi"`
+	Info       OpenApiInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenApiOperation `json:"paths"`
+	Components OpenApiComponents                       `json:"components"`
+}
+
+type OpenApiInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenApiComponents struct {
+	Schemas map[string]*OpenApiSchema `json:"schemas"`
+}
+
+type OpenApiOperation struct {
+	OperationId string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*OpenApiParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenApiRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenApiResponse `json:"responses"`
+}
+
+type OpenApiParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"` // path, query, header, cookie
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenApiSchema `json:"schema"`
+}
+
+type OpenApiRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenApiMediaType `json:"content"`
+}
+
+type OpenApiMediaType struct {
+	Schema *OpenApiSchema `json:"schema,omitempty"`
+}
+
+type OpenApiResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenApiMediaType `json:"content,omitempty"`
+}
+
+type OpenApiSchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *OpenApiSchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenApiSchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenApiSchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+}
+
+// 包装类型的参数位置和标签，用于生成文档
+type mappingSourceDoc interface {
+	openApiSource() (in string, tag string)
+}
+
+func (param *Header[T]) openApiSource() (string, string) {
+	return "header", "header"
+}
+
+func (param *Cookie[T]) openApiSource() (string, string) {
+	return "cookie", "cookie"
+}
+
+func (param *Query[T]) openApiSource() (string, string) {
+	return "query", "form"
+}
+
+func (param *Form[T]) openApiSource() (string, string) {
+	return "form", "form"
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+	readerType     = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	apiRespType    = reflect.TypeOf(ApiResp{})
+	ctxRespType    = reflect.TypeOf(CtxResp{})
+)
+
+// 请求参数的来源标签，结构体字段使用这些标签时不作为请求体的字段
+var openApiSourceTags = []string{"uri", "header", "cookie", "query"}
+
+// 文档生成器
+type openApiBuilder struct {
+	boot *GobootApplication
+	doc  *OpenApiDoc
+	// 结构体类型对应的组件名称
+	names map[reflect.Type]string
+	// 已经使用的 operationId
+	operationIds map[string]bool
+}
+
+// 使用 Run 时编译的路由表生成 OpenAPI 文档
+func (boot *GobootApplication) OpenApiDoc() *OpenApiDoc {
+	config := boot.Config.Goboot.Server.OpenApi
+	title := config.Title
+	if title == "" {
+		title = boot.Config.Goboot.Application.Name
+	}
+	if title == "" {
+		title = "goboot"
+	}
+	version := config.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+	builder := &openApiBuilder{
+		boot: boot,
+		doc: &OpenApiDoc{
+			OpenApi: "3.0.3",
+			Info: OpenApiInfo{
+				Title:       title,
+				Version:     version,
+				Description: config.Description,
+			},
+			Paths: map[string]map[string]*OpenApiOperation{},
+			Components: OpenApiComponents{
+				Schemas: map[string]*OpenApiSchema{},
+			},
+		},
+		names:        map[reflect.Type]string{},
+		operationIds: map[string]bool{},
+	}
+	for _, route := range boot.MappingRoutes() {
+		builder.addRoute(route)
+	}
+	return builder.doc
+}
+
+// 注册文档和 Swagger UI 的路由，在 Run 时调用，错误记录为启动错误
+func (boot *GobootApplication) registerOpenApi() {
+	config := boot.Config.Goboot.Server.OpenApi
+	if !config.Enable {
+		return
+	}
+	docPath := config.Path
+	if docPath == "" {
+		docPath = DefaultOpenApiPath
+	}
+	uiPath := config.UiPath
+	if uiPath == "" {
+		uiPath = DefaultOpenApiUiPath
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			boot.startupErrors = append(boot.startupErrors, fmt.Sprintf("goboot.openapi: register route failure, %v", rec))
+		}
+	}()
+
+	// 路由表在 Run 之后不再变化，只生成一次
+	var once sync.Once
+	var body []byte
+	var err error
+	boot.App.GET(docPath, func(c *gin.Context) {
+		once.Do(func() {
+			body, err = json.Marshal(boot.OpenApiDoc())
+		})
+		if err != nil {
+			boot.HandleError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	})
+	LogInfo("goboot enable openapi: %v", docPath)
+
+	fileServer := boot.Config.Goboot.Server.FileServer
+	if !fileServer.Enable || fileServer.EmbedStaticFs == nil {
+		LogWarn("goboot openapi swagger ui disabled, require goboot.server.fileServer enable with EmbedStaticFs")
+		return
+	}
+	if _, err := fs.Stat(fileServer.EmbedStaticFs, openApiUiFile); err != nil {
+		LogWarn("goboot openapi swagger ui disabled, not found %v in EmbedStaticFs", openApiUiFile)
+		return
+	}
+	target := fileServerBasePath(fileServer) + "/public/" + openApiUiFile + "?url=" + url.QueryEscape(docPath)
+	boot.App.GET(uiPath, func(c *gin.Context) {
+		c.Redirect(http.StatusFound, target)
+	})
+	LogInfo("goboot enable swagger ui: %v --> %v", uiPath, target)
+}
+
+// 添加路由的操作
+func (builder *openApiBuilder) addRoute(route *MappingRoute) {
+	docPath := openApiPath(route.Path)
+	methods := []string{route.Method}
+	if route.Method == MappingMethodAny {
+		methods = []string{http.MethodGet, http.MethodPost}
+	}
+	if builder.doc.Paths[docPath] == nil {
+		builder.doc.Paths[docPath] = map[string]*OpenApiOperation{}
+	}
+	for _, method := range methods {
+		builder.doc.Paths[docPath][strings.ToLower(method)] = builder.operation(route, method)
+	}
+}
+
+// gin路径转换为OpenAPI路径，例如 /user/:id/*path --> /user/{id}/{path}
+func openApiPath(urlPath string) string {
+	items := strings.Split(urlPath, "/")
+	for idx, item := range items {
+		if strings.HasPrefix(item, ":") || strings.HasPrefix(item, "*") {
+			items[idx] = "{" + item[1:] + "}"
+		}
+	}
+	return strings.Join(items, "/")
+}
+
+// 生成路由的操作
+func (builder *openApiBuilder) operation(route *MappingRoute, method string) *OpenApiOperation {
+	handlerType := indirectType(reflect.TypeOf(route.Handler))
+	operationId := strings.ReplaceAll(route.HandlerName, ".", "_")
+	if route.Method == MappingMethodAny {
+		operationId += "_" + strings.ToLower(method)
+	}
+	for idx := 2; builder.operationIds[operationId]; idx++ {
+		operationId = strings.ReplaceAll(route.HandlerName, ".", "_") + "_" + strconv.Itoa(idx)
+	}
+	builder.operationIds[operationId] = true
+
+	op := &OpenApiOperation{
+		OperationId: operationId,
+		Summary:     route.HandlerName,
+		Description: fmt.Sprintf("%v %v", route.Type, route.Base),
+		Tags:        []string{handlerType.Name()},
+		Responses:   map[string]*OpenApiResponse{},
+	}
+
+	// 路径变量默认为字符串
+	pathSchemas := map[string]*OpenApiSchema{}
+	// 没有请求体的请求方式，结构体参数使用查询参数
+	queryOnly := method == http.MethodGet || method == http.MethodDelete || method == http.MethodHead
+	bodyForm := &OpenApiSchema{Type: "object", Properties: map[string]*OpenApiSchema{}}
+	var bodyJson *OpenApiSchema
+	hasFile := false
+	binds := false
+	pathArgs := 0
+
+	addParams := func(params []*OpenApiParameter) {
+		for _, item := range params {
+			if item.In == "path" {
+				pathSchemas[item.Name] = item.Schema
+				continue
+			}
+			op.Parameters = append(op.Parameters, item)
+		}
+	}
+
+	for _, arg := range route.argTypes {
+		switch {
+		case builder.boot.isMappingPathArg(arg):
+			if pathArgs < len(route.Params) {
+				pathSchemas[route.Params[pathArgs]] = builder.schemaOf(arg)
+			}
+			pathArgs++
+			binds = true
+		case arg == fileHeaderType:
+			hasFile = true
+			bodyForm.Properties["file"] = &OpenApiSchema{Type: "string", Format: "binary"}
+			bodyForm.Required = append(bodyForm.Required, "file")
+		case arg == reflect.SliceOf(fileHeaderType):
+			hasFile = true
+			bodyForm.Properties["files"] = &OpenApiSchema{Type: "array", Items: &OpenApiSchema{Type: "string", Format: "binary"}}
+		case builder.boot.isInjectedArg(arg):
+			// 框架对象、服务和配置，不是请求参数
+		case isMappingSourceArg(arg):
+			binds = true
+			wrapper := indirectType(arg)
+			source, ok := reflect.New(wrapper).Interface().(mappingSourceDoc)
+			if !ok || wrapper.NumField() == 0 {
+				continue
+			}
+			in, tag := source.openApiSource()
+			valueType := wrapper.Field(0).Type
+			if in == "form" {
+				form := builder.structSchema(valueType, tag, nil)
+				mergeOpenApiSchema(bodyForm, form)
+				hasFile = hasFile || structHasFile(valueType)
+				continue
+			}
+			addParams(builder.structParams(valueType, tag, in, false))
+		case indirectType(arg).Kind() == reflect.Struct:
+			binds = true
+			structType := indirectType(arg)
+			addParams(builder.structParams(structType, "uri", "path", false))
+			addParams(builder.structParams(structType, "header", "header", false))
+			addParams(builder.structParams(structType, "cookie", "cookie", false))
+			addParams(builder.structParams(structType, "query", "query", false))
+			if queryOnly {
+				addParams(builder.structParams(structType, "form", "query", true))
+				continue
+			}
+			mergeOpenApiSchema(bodyForm, builder.structSchema(structType, "form", openApiSourceTags))
+			hasFile = hasFile || structHasFile(structType)
+			bodyJson = builder.structSchema(structType, "json", openApiSourceTags)
+		}
+	}
+
+	// 路径变量在最前面，按照路径中的顺序
+	pathParams := []*OpenApiParameter{}
+	for _, name := range route.Params {
+		schema := pathSchemas[name]
+		if schema == nil {
+			schema = &OpenApiSchema{Type: "string"}
+		}
+		pathParams = append(pathParams, &OpenApiParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+	}
+	op.Parameters = append(pathParams, op.Parameters...)
+
+	// 请求体
+	if !queryOnly && (bodyJson != nil || len(bodyForm.Properties) > 0) {
+		op.RequestBody = &OpenApiRequestBody{
+			Content: map[string]*OpenApiMediaType{},
+		}
+		if bodyJson != nil && !hasFile {
+			op.RequestBody.Content["application/json"] = &OpenApiMediaType{Schema: bodyJson}
+		}
+		if len(bodyForm.Properties) > 0 {
+			if hasFile {
+				op.RequestBody.Content["multipart/form-data"] = &OpenApiMediaType{Schema: bodyForm}
+			} else {
+				op.RequestBody.Content["application/x-www-form-urlencoded"] = &OpenApiMediaType{Schema: bodyForm}
+			}
+		}
+		op.RequestBody.Required = len(bodyForm.Required) > 0 || (bodyJson != nil && len(bodyJson.Required) > 0)
+		if len(op.RequestBody.Content) == 0 {
+			op.RequestBody = nil
+		}
+	}
+
+	// 响应
+	op.Responses["200"] = builder.response(route)
+	if binds || hasFile {
+		op.Responses["400"] = &OpenApiResponse{
+			Description: "invalid request params",
+			Content: map[string]*OpenApiMediaType{
+				"application/json": {Schema: builder.schemaOf(apiRespType)},
+			},
+		}
+	}
+	if len(route.Permissions) > 0 {
+		op.Responses["401"] = &OpenApiResponse{
+			Description: "unauthorized",
+			Content: map[string]*OpenApiMediaType{
+				"application/json": {Schema: builder.schemaOf(apiRespType)},
+			},
+		}
+		op.Responses["403"] = &OpenApiResponse{
+			Description: "forbidden, require one of: " + strings.Join(route.Permissions, ", "),
+			Content: map[string]*OpenApiMediaType{
+				"application/json": {Schema: builder.schemaOf(apiRespType)},
+			},
+		}
+	}
+	if route.fn.IsValid() {
+		fnType := route.fn.Type()
+		for p := 0; p < fnType.NumOut(); p++ {
+			if fnType.Out(p).Implements(errorType) {
+				op.Responses["default"] = &OpenApiResponse{
+					Description: "error",
+					Content: map[string]*OpenApiMediaType{
+						"application/json": {Schema: builder.schemaOf(apiRespType)},
+					},
+				}
+				break
+			}
+		}
+	}
+	return op
+}
+
+// 是否为注入的框架对象、服务或配置，不生成请求参数
+func (boot *GobootApplication) isInjectedArg(arg reflect.Type) bool {
+	if _, ok := boot.argInjector(arg); ok {
+		return true
+	}
+	if boot.hasService(arg) {
+		return true
+	}
+	if _, ok := boot.ConfigBeans[arg]; ok {
+		return true
+	}
+	_, ok := boot.ConfigBeans[reflect.PtrTo(arg)]
+	return ok
+}
+
+// 生成成功的响应，使用第一个不是 error 的返回值
+func (builder *openApiBuilder) response(route *MappingRoute) *OpenApiResponse {
+	resp := &OpenApiResponse{Description: "OK"}
+	if !route.fn.IsValid() {
+		return resp
+	}
+	wrap := builder.boot.Config.Goboot.Server.Mapping.WrapApiResp
+	fnType := route.fn.Type()
+	var retType reflect.Type
+	for p := 0; p < fnType.NumOut(); p++ {
+		if !fnType.Out(p).Implements(errorType) {
+			retType = fnType.Out(p)
+			break
+		}
+	}
+	if retType == nil {
+		return resp
+	}
+	elemType := indirectType(retType)
+	switch {
+	case elemType == ctxRespType:
+		// 函数自行响应
+		return resp
+	case elemType == apiRespType:
+		resp.Content = map[string]*OpenApiMediaType{
+			"application/json": {Schema: builder.schemaOf(apiRespType)},
+		}
+		return resp
+	case retType.Kind() == reflect.String:
+		resp.Content = map[string]*OpenApiMediaType{
+			"text/plain": {Schema: &OpenApiSchema{Type: "string"}},
+		}
+		return resp
+	case retType.Kind() == reflect.Slice && retType.Elem().Kind() == reflect.Uint8,
+		retType.Implements(readerType):
+		resp.Content = map[string]*OpenApiMediaType{
+			"application/octet-stream": {Schema: &OpenApiSchema{Type: "string", Format: "binary"}},
+		}
+		return resp
+	}
+	schema := builder.schemaOf(retType)
+	if wrap {
+		// 与 ApiResp 的结构一致，data 为返回值
+		schema = &OpenApiSchema{
+			Type: "object",
+			Properties: map[string]*OpenApiSchema{
+				"code":      {Type: "integer", Format: "int64"},
+				"msg":       {Type: "string"},
+				"data":      schema,
+				"requestId": {Type: "string"},
+			},
+		}
+	}
+	resp.Content = map[string]*OpenApiMediaType{
+		"application/json": {Schema: schema},
+	}
+	return resp
+}
+
+// 获取类型的结构，结构体使用组件引用
+func (builder *openApiBuilder) schemaOf(rtype reflect.Type) *OpenApiSchema {
+	rtype = indirectType(rtype)
+	switch {
+	case rtype == timeType:
+		return &OpenApiSchema{Type: "string", Format: "date-time"}
+	case rtype == indirectType(fileHeaderType):
+		return &OpenApiSchema{Type: "string", Format: "binary"}
+	}
+	switch rtype.Kind() {
+	case reflect.Bool:
+		return &OpenApiSchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenApiSchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &OpenApiSchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenApiSchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenApiSchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenApiSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if rtype.Elem().Kind() == reflect.Uint8 {
+			return &OpenApiSchema{Type: "string", Format: "byte"}
+		}
+		return &OpenApiSchema{Type: "array", Items: builder.schemaOf(rtype.Elem())}
+	case reflect.Map:
+		return &OpenApiSchema{Type: "object", AdditionalProperties: builder.schemaOf(rtype.Elem())}
+	case reflect.Struct:
+		return &OpenApiSchema{Ref: "#/components/schemas/" + builder.component(rtype)}
+	}
+	// interface{} 等任意类型
+	return &OpenApiSchema{}
+}
+
+// 注册结构体组件，返回组件名称
+func (builder *openApiBuilder) component(rtype reflect.Type) string {
+	if name, ok := builder.names[rtype]; ok {
+		return name
+	}
+	name := openApiComponentName(rtype.Name())
+	if name == "" {
+		name = "Object"
+	}
+	// 不同包的同名类型，使用包名区分
+	if _, ok := builder.doc.Components.Schemas[name]; ok {
+		pkg := rtype.PkgPath()
+		if idx := strings.LastIndex(pkg, "/"); idx >= 0 {
+			pkg = pkg[idx+1:]
+		}
+		name = openApiComponentName(pkg) + "_" + name
+		for idx := 2; builder.doc.Components.Schemas[name] != nil; idx++ {
+			name = openApiComponentName(pkg) + "_" + openApiComponentName(rtype.Name()) + strconv.Itoa(idx)
+		}
+	}
+	builder.names[rtype] = name
+	// 先占位，避免递归的结构体重复生成
+	schema := &OpenApiSchema{Type: "object"}
+	builder.doc.Components.Schemas[name] = schema
+	*schema = *builder.structSchema(rtype, "json", nil)
+	return name
+}
+
+// 组件名称只保留字母、数字和下划线，例如泛型类型 Page[main.User] --> Page_main_User
+func openApiComponentName(name string) string {
+	ret := strings.Builder{}
+	for _, ch := range name {
+		if ch == '_' || (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') {
+			ret.WriteRune(ch)
+		} else if ch == '.' || ch == '[' || ch == ',' {
+			ret.WriteRune('_')
+		}
+	}
+	return ret.String()
+}
+
+// 结构体字段的名称，没有标签时使用字段名，返回空表示忽略
+func openApiFieldName(field reflect.StructField, tag string) (string, bool) {
+	val, ok := field.Tag.Lookup(tag)
+	name := strings.Split(val, ",")[0]
+	if name == "-" {
+		return "", ok
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, ok
+}
+
+// 字段是否使用了来源标签
+func hasAnyTag(field reflect.StructField, tags []string) bool {
+	for _, tag := range tags {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 生成结构体的内联结构，exclude 中的标签字段不包含
+func (builder *openApiBuilder) structSchema(rtype reflect.Type, tag string, exclude []string) *OpenApiSchema {
+	rtype = indirectType(rtype)
+	schema := &OpenApiSchema{Type: "object", Properties: map[string]*OpenApiSchema{}}
+	if rtype.Kind() != reflect.Struct {
+		return builder.schemaOf(rtype)
+	}
+	for i := 0; i < rtype.NumField(); i++ {
+		field := rtype.Field(i)
+		if !field.IsExported() || hasAnyTag(field, exclude) {
+			continue
+		}
+		name, tagged := openApiFieldName(field, tag)
+		if name == "" {
+			continue
+		}
+		// 没有标签的匿名结构体，字段合并到当前结构
+		if field.Anonymous && !tagged && indirectType(field.Type).Kind() == reflect.Struct {
+			mergeOpenApiSchema(schema, builder.structSchema(field.Type, tag, exclude))
+			continue
+		}
+		prop := builder.schemaOf(field.Type)
+		if applyBindingRules(prop, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = prop
+	}
+	return schema
+}
+
+// 合并结构的字段
+func mergeOpenApiSchema(target *OpenApiSchema, source *OpenApiSchema) {
+	for name, prop := range source.Properties {
+		target.Properties[name] = prop
+	}
+	target.Required = append(target.Required, source.Required...)
+}
+
+// 生成结构体中指定标签字段的参数
+// untagged 为true时，没有任何来源标签的字段也作为参数，用于 form 标签的查询参数
+func (builder *openApiBuilder) structParams(rtype reflect.Type, tag string, in string, untagged bool) []*OpenApiParameter {
+	rtype = indirectType(rtype)
+	ret := []*OpenApiParameter{}
+	if rtype.Kind() != reflect.Struct {
+		return ret
+	}
+	for i := 0; i < rtype.NumField(); i++ {
+		field := rtype.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, tagged := openApiFieldName(field, tag)
+		fieldType := indirectType(field.Type)
+		if field.Anonymous && !tagged && fieldType.Kind() == reflect.Struct {
+			ret = append(ret, builder.structParams(fieldType, tag, in, untagged)...)
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		if !tagged {
+			if !untagged || hasAnyTag(field, append([]string{"json"}, openApiSourceTags...)) {
+				continue
+			}
+		} else if untagged && hasAnyTag(field, openApiSourceTags) {
+			continue
+		}
+		// 查询参数只支持普通类型和切片
+		if fieldType.Kind() == reflect.Struct && fieldType != timeType {
+			continue
+		}
+		schema := builder.schemaOf(field.Type)
+		required := applyBindingRules(schema, field.Tag.Get("binding"))
+		ret = append(ret, &OpenApiParameter{
+			Name:     name,
+			In:       in,
+			Required: required || in == "path",
+			Schema:   schema,
+		})
+	}
+	return ret
+}
+
+// 结构体中是否有上传文件的字段
+func structHasFile(rtype reflect.Type) bool {
+	rtype = indirectType(rtype)
+	if rtype.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < rtype.NumField(); i++ {
+		fieldType := rtype.Field(i).Type
+		if fieldType == fileHeaderType || fieldType == reflect.SliceOf(fileHeaderType) {
+			return true
+		}
+	}
+	return false
+}
+
+// 使用 binding 标签设置结构的限制，返回是否必填
+func applyBindingRules(schema *OpenApiSchema, rules string) bool {
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			if schema.Ref == "" {
+				schema.Enum = strings.Fields(param)
+			}
+		case "min", "gte", "max", "lte":
+			if schema.Ref != "" {
+				continue
+			}
+			num, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			lower := name == "min" || name == "gte"
+			switch schema.Type {
+			case "integer", "number":
+				if lower {
+					schema.Minimum = &num
+				} else {
+					schema.Maximum = &num
+				}
+			case "string":
+				size := int(num)
+				if lower {
+					schema.MinLength = &size
+				} else {
+					schema.MaxLength = &size
+				}
+			}
+		}
+	}
+	return required
+}
@@ -0,0 +1,43 @@
+package goboot
+
+import (
+	"testing"
+)
+
+func TestOpenApiPath(t *testing.T) {
+	if got := openApiPath("/user/:id/files/*path"); got != "/user/{id}/files/{path}" {
+		t.Fatalf("path = %v", got)
+	}
+}
+
+func TestOpenApiDoc(t *testing.T) {
+	boot := newMappingTestApp(t, &pathTestApi{}, &validationTestApi{})
+	doc := boot.OpenApiDoc()
+	if doc.Info.Title != "goboot" || doc.Info.Version != "1.0.0" {
+		t.Fatalf("info = %+v", doc.Info)
+	}
+
+	orders := doc.Paths["/api/user/{id}/orders"]["get"]
+	if orders == nil {
+		t.Fatalf("paths = %v", doc.Paths)
+	}
+	params := map[string]string{}
+	for _, item := range orders.Parameters {
+		params[item.Name] = item.In
+	}
+	if params["id"] != "path" || params["page"] != "query" {
+		t.Fatalf("orders parameters = %v", params)
+	}
+	if doc.Paths["/api/user/{id}"]["delete"] == nil {
+		t.Fatalf("delete user operation not found")
+	}
+
+	user := doc.Paths["/api/user"]["post"]
+	if user == nil || user.RequestBody == nil || user.Responses["400"] == nil {
+		t.Fatalf("post user operation = %+v", user)
+	}
+	schema := user.RequestBody.Content["application/json"].Schema
+	if len(schema.Required) != 1 || schema.Required[0] != "name" || schema.Properties["age"].Minimum == nil {
+		t.Fatalf("request body schema = %+v", schema)
+	}
+}
@@ -0,0 +1,175 @@
+package goboot
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 权限检查区
+// 处理器和控制器实现 Permissions() 时，可以为函数声明需要的角色或权限
+// 键为函数名，可以使用去除请求方式前缀的函数名，* 表示没有单独声明的其他函数
+// 值为需要的角色或权限，当前用户拥有其中一个即可，为空时不检查
+//
+// func (api *OrderApi) Permissions() map[string][]string {
+// 	return map[string][]string{
+// 		"XP_Order_Save": {"admin", "order:write"},
+// 		"Order_Export":  {"order:export"},
+// 		"*":             {"order:read"},
+// 	}
+// }
+//
+// 在参数注入之前检查，未认证时响应401，没有权限时响应403
+// 当前用户的授权默认为 Principal.Roles，设置 PermissionProvider 后使用自定义的授权
+// 授权使用 path.Match 匹配需要的权限，例如授权 order:* 匹配 order:read，授权 * 匹配全部权限
+// 开启redis并配置 goboot.server.permission.cacheExpire 时，PermissionProvider 的授权缓存到redis
+// /////////////////////////////////////////////////////////
+
+// 授权缓存在redis中的键前缀
+const permissionRedisKeyPrefix string = "goboot:grants:"
+
+// 权限配置
+type Permission struct {
+	CacheExpire int `yaml:"cacheExpire"` // 授权在redis中的缓存时间，单位秒，0不缓存
+}
+
+// 声明函数需要的角色或权限的处理器
+type PermissionDeclarer interface {
+	Permissions() map[string][]string
+}
+
+// 加载当前用户的授权，包括角色和权限
+type PermissionProvider interface {
+	Grants(principal *Principal) ([]string, error)
+}
+
+// 使用函数实现 PermissionProvider
+type PermissionProviderFunc func(principal *Principal) ([]string, error)
+
+func (fn PermissionProviderFunc) Grants(principal *Principal) ([]string, error) {
+	return fn(principal)
+}
+
+// 解析处理器声明的权限，键为函数名
+func parseMappingPermissions(handler interface{}) map[string][]string {
+	declarer, ok := handler.(PermissionDeclarer)
+	if !ok {
+		return map[string][]string{}
+	}
+	ret := map[string][]string{}
+	for key, items := range declarer.Permissions() {
+		ret[key] = items
+	}
+	return ret
+}
+
+// 函数需要的权限，依次使用函数名，去除请求方式前缀的函数名，以及 *
+func mappingFuncPermissions(permissions map[string][]string, funcName string, name string) []string {
+	if items, ok := permissions[funcName]; ok {
+		return items
+	}
+	if items, ok := permissions[name]; ok {
+		return items
+	}
+	return permissions["*"]
+}
+
+// 检查声明的函数是否存在
+func checkMappingPermissions(htype reflect.Type, permissions map[string][]string) error {
+	for key := range permissions {
+		if key == "*" {
+			continue
+		}
+		found := false
+		for i := 0; i < htype.NumMethod(); i++ {
+			funcName := htype.Method(i).Name
+			_, name := ParseMappingFuncName(funcName)
+			if funcName == key || name == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("function %v not found", key)
+		}
+	}
+	return nil
+}
+
+// 授权是否满足其中一个需要的权限
+func matchGrants(grants []string, required []string) bool {
+	for _, grant := range grants {
+		for _, item := range required {
+			if ok, _ := path.Match(grant, item); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 检查当前用户是否拥有路由需要的权限
+func (boot *GobootApplication) checkRoutePermissions(c *gin.Context, route *MappingRoute) error {
+	principal := GetPrincipal(c)
+	if principal == nil {
+		return NewStatusError(401, "unauthorized")
+	}
+	grants, err := boot.PrincipalGrants(principal)
+	if err != nil {
+		return WrapStatusError(500, err)
+	}
+	if !matchGrants(grants, route.Permissions) {
+		return NewStatusError(403, "forbidden")
+	}
+	return nil
+}
+
+// 获取当前用户的授权
+// 未设置 PermissionProvider 时为 Principal.Roles
+func (boot *GobootApplication) PrincipalGrants(principal *Principal) ([]string, error) {
+	if boot.PermissionProvider == nil {
+		return principal.Roles, nil
+	}
+	expire := boot.Config.Goboot.Server.Permission.CacheExpire
+	if boot.Redis == nil || expire <= 0 || principal.Id == "" {
+		return boot.PermissionProvider.Grants(principal)
+	}
+
+	key := permissionRedisKeyPrefix + principal.Id
+	str, err := boot.Redis.Redis.Get(boot.Redis.Context, key).Result()
+	if err == nil {
+		grants := []string{}
+		if err := json.Unmarshal([]byte(str), &grants); err == nil {
+			return grants, nil
+		}
+	} else if err != goredis.Nil {
+		LogWarn("goboot permission cache read failure: %v", err)
+	}
+
+	grants, err := boot.PermissionProvider.Grants(principal)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := json.Marshal(grants)
+	if err == nil {
+		err = boot.Redis.Redis.Set(boot.Redis.Context, key, string(bytes), time.Duration(expire)*time.Second).Err()
+	}
+	if err != nil {
+		LogWarn("goboot permission cache write failure: %v", err)
+	}
+	return grants, nil
+}
+
+// 删除用户缓存的授权，授权变化时调用
+func (boot *GobootApplication) EvictPermissionCache(principalId string) error {
+	if boot.Redis == nil {
+		return nil
+	}
+	return boot.Redis.Redis.Del(boot.Redis.Context, permissionRedisKeyPrefix+principalId).Err()
+}
@@ -0,0 +1,150 @@
+package goboot
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type permissionTestApi struct{}
+
+func (api *permissionTestApi) Permissions() map[string][]string {
+	return map[string][]string{
+		"XP_Order_Save": {"admin", "order:write"},
+		"Order_Export":  {"order:export"},
+		"*":             {"order:read"},
+		"XP_Login":      {},
+	}
+}
+
+func (api *permissionTestApi) XP_Order_Save() string { return "save" }
+func (api *permissionTestApi) Order_Export() string  { return "export" }
+func (api *permissionTestApi) Order_List() string    { return "list" }
+
+func (api *permissionTestApi) XP_Login(boot *GobootApplication, c *gin.Context) (string, error) {
+	roles := strings.Split(c.GetHeader("X-Roles"), ",")
+	return boot.Login(c, &Principal{Id: c.GetHeader("X-User"), Roles: roles})
+}
+
+type permissionTestMissing struct{}
+
+func (api *permissionTestMissing) Permissions() map[string][]string {
+	return map[string][]string{"Order_Remove": {"admin"}}
+}
+
+func (api *permissionTestMissing) Order_Info() string { return "" }
+
+func TestMatchGrants(t *testing.T) {
+	cases := []struct {
+		grants   []string
+		required []string
+		want     bool
+	}{
+		{[]string{"admin"}, []string{"admin", "order:write"}, true},
+		{[]string{"order:*"}, []string{"order:read"}, true},
+		{[]string{"*"}, []string{"anything"}, true},
+		{[]string{"order:read"}, []string{"order:write"}, false},
+		{nil, []string{"admin"}, false},
+	}
+	for _, item := range cases {
+		if got := matchGrants(item.grants, item.required); got != item.want {
+			t.Errorf("matchGrants(%v, %v) = %v, want %v", item.grants, item.required, got, item.want)
+		}
+	}
+}
+
+func TestMappingFuncPermissions(t *testing.T) {
+	permissions := parseMappingPermissions(&permissionTestApi{})
+	cases := map[[2]string][]string{
+		{"XP_Order_Save", "Order_Save"}:     {"admin", "order:write"},
+		{"XG_Order_Export", "Order_Export"}: {"order:export"},
+		{"Order_List", "Order_List"}:        {"order:read"},
+	}
+	for key, want := range cases {
+		if got := mappingFuncPermissions(permissions, key[0], key[1]); !reflect.DeepEqual(got, want) {
+			t.Errorf("permissions of %v = %v, want %v", key[0], got, want)
+		}
+	}
+	if err := checkMappingPermissions(reflect.TypeOf(&permissionTestApi{}), permissions); err != nil {
+		t.Errorf("check error: %v", err)
+	}
+	missing := parseMappingPermissions(&permissionTestMissing{})
+	if err := checkMappingPermissions(reflect.TypeOf(&permissionTestMissing{}), missing); err == nil {
+		t.Errorf("expected error for undeclared function")
+	}
+}
+
+func TestMappingRoutePermissions(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.Mapping.Enable = true
+	config.Goboot.Server.Mapping.Items = []string{"/api/"}
+	config.Goboot.Server.Auth = Auth{
+		Enable:   true,
+		Includes: []string{"/api/admin/**"},
+		Excludes: []string{"/api/login"},
+	}
+	boot := GetConfigApplication(config, nil)
+	boot.AddHandlers(&permissionTestApi{})
+	boot.compileMappings()
+	if len(boot.startupErrors) > 0 {
+		t.Fatalf("startup errors: %v", boot.startupErrors)
+	}
+
+	login := func(user string, roles string) string {
+		req := httptest.NewRequest("POST", "/api/login", nil)
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Roles", roles)
+		rec := httptest.NewRecorder()
+		boot.App.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+	reader := login("1", "order:read")
+	writer := login("2", "order:*")
+
+	cases := []struct {
+		method string
+		target string
+		token  string
+		code   int
+	}{
+		{"GET", "/api/order/list", "", 401},
+		{"GET", "/api/order/list", reader, 200},
+		{"GET", "/api/order/export", reader, 403},
+		{"POST", "/api/order/save", reader, 403},
+		{"POST", "/api/Order_Save", reader, 403},
+		{"POST", "/api/order/save", writer, 200},
+		{"GET", "/api/order/export", writer, 200},
+	}
+	for _, item := range cases {
+		if rec := serveAuthTest(boot, item.method, item.target, item.token); rec.Code != item.code {
+			t.Errorf("%v %v = %v, want %v, body %v", item.method, item.target, rec.Code, item.code, rec.Body.String())
+		}
+	}
+
+	// 设置 PermissionProvider 后使用自定义的授权
+	boot.PermissionProvider = PermissionProviderFunc(func(principal *Principal) ([]string, error) {
+		if principal.Id == "2" {
+			return nil, errors.New("load grants failure")
+		}
+		return []string{"admin"}, nil
+	})
+	if rec := serveAuthTest(boot, "POST", "/api/order/save", reader); rec.Code != 200 {
+		t.Errorf("status with provider grants = %v", rec.Code)
+	}
+	if rec := serveAuthTest(boot, "GET", "/api/order/list", writer); rec.Code != 500 {
+		t.Errorf("status with provider error = %v", rec.Code)
+	}
+}
+
+func TestMappingPermissionsUndeclaredFunction(t *testing.T) {
+	boot := newMappingTestApp(t, &permissionTestMissing{})
+	if !strings.Contains(strings.Join(boot.startupErrors, "\n"), "function Order_Remove not found") {
+		t.Fatalf("startup errors = %v", boot.startupErrors)
+	}
+}
@@ -0,0 +1,558 @@
+package goboot
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 限流区
+// 开启 goboot.server.rateLimit 后，按照规则对请求限流，超过限制时响应429，并设置 Retry-After
+// 响应头 X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset 为剩余最少的规则
+//
+// 规则的匹配条件，为空的条件不限制，多个条件需要同时满足：
+// paths 路径模式，与令牌认证相同，以 /** 结尾时按照前缀匹配，作用于映射、控制器、代理和文件服务
+// methods 映射函数的匹配模式，使用 path.Match 匹配函数名或者处理器类型和函数名，例如 XP_*, Api.XP_Login
+// httpMethods 请求方式
+//
+// 规则的计数维度 key：
+// ip 客户端IP，默认值；token 令牌认证或者JWT认证的用户；header:名称 请求头，例如 header:X-Api-Key；global 全部请求共用
+// token 在限流时校验令牌，令牌无效时使用客户端IP，随机令牌不能绕过限流
+// header 的值由客户端发送，只适用于网关已经校验过的API密钥等请求头
+// 请求中没有有效令牌或者请求头时使用客户端IP
+// 客户端IP只从 goboot.server.trustedProxies 中代理的转发头获取，默认为连接的地址
+//
+// 算法 algorithm：
+// tokenBucket 令牌桶，容量为 limit，每 window 秒补充 limit 个令牌，允许突发
+// slidingWindow 滑动窗口，使用前一个窗口的计数按照时间加权，window 秒内最多 limit 个请求
+//
+// 存储 store：memory 内存，只适用于单个节点，计数数量达到上限后新的计数维度共用一个计数
+// redis 使用 RedisCli 和 Lua 脚本，适用于集群
+// redis 出错时不限流，只打印日志
+// /////////////////////////////////////////////////////////
+
+// 限流在redis中的键前缀
+const rateLimitRedisKeyPrefix string = "goboot:ratelimit:"
+
+// 限流算法
+const (
+	RateLimitTokenBucket   string = "tokenBucket"
+	RateLimitSlidingWindow string = "slidingWindow"
+)
+
+// 每检查多少次清理一次内存中过期的计数
+const memoryRateLimitSweepCount int = 1024
+
+// 内存中默认最多保存的计数数量
+const DefaultMemoryRateLimitMaxStates int = 100000
+
+// 限流配置
+type RateLimit struct {
+	Enable bool            `yaml:"enable"`
+	Store  string          `yaml:"store"` // 计数存储，memory/redis，默认memory
+	Rules  []RateLimitRule `yaml:"rules"`
+}
+
+// 限流规则
+type RateLimitRule struct {
+	Name        string   `yaml:"name"`        // 规则名称，用于区分计数，默认 rule{序号}
+	Paths       []string `yaml:"paths"`       // 路径模式，例如 /api/**, /file-server/download/**
+	Methods     []string `yaml:"methods"`     // 映射函数的匹配模式，例如 XP_Login, Api.XP_*
+	HttpMethods []string `yaml:"httpMethods"` // 请求方式
+	Key         string   `yaml:"key"`         // 计数维度，ip/token/header:名称/global，默认ip
+	Algorithm   string   `yaml:"algorithm"`   // 算法，tokenBucket/slidingWindow，默认tokenBucket
+	Limit       int      `yaml:"limit"`       // 窗口内允许的请求数，令牌桶的容量
+	Window      int      `yaml:"window"`      // 窗口时间，单位秒
+}
+
+// 限流检查的结果
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // 被限流时，下一个请求可以通过的等待时间
+	Reset      time.Duration // 计数恢复的等待时间
+}
+
+// 限流计数存储
+// 出错时不限流
+type RateLimitStore interface {
+	Allow(key string, rule RateLimitRule) (*RateLimitResult, error)
+}
+
+// 规则名称
+func (rule RateLimitRule) name(idx int) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return fmt.Sprintf("rule%v", idx)
+}
+
+// 窗口时间
+func (rule RateLimitRule) window() time.Duration {
+	return time.Duration(rule.Window) * time.Second
+}
+
+// 规则是否作用于这个请求，route 为请求对应的映射路由，路径模式同时使用路由的路径
+func (rule RateLimitRule) matchRequest(c *gin.Context, route *MappingRoute) bool {
+	if len(rule.Paths) > 0 && !matchPathPatterns(rule.Paths, c.Request.URL.Path) &&
+		(route == nil || !matchPathPatterns(rule.Paths, route.Path)) {
+		return false
+	}
+	if len(rule.HttpMethods) > 0 {
+		matched := false
+		for _, item := range rule.HttpMethods {
+			if strings.EqualFold(item, c.Request.Method) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// 规则是否作用于这个映射函数
+func (rule RateLimitRule) matchRoute(route *MappingRoute) bool {
+	for _, item := range rule.Methods {
+		ok1, _ := path.Match(item, route.FuncName)
+		ok2, _ := path.Match(item, route.HandlerName)
+		if ok1 || ok2 {
+			return true
+		}
+	}
+	return false
+}
+
+// 计数维度的值，没有有效令牌或者请求头时使用客户端IP
+func (boot *GobootApplication) rateLimitIdentity(c *gin.Context, rule RateLimitRule) string {
+	key := rule.Key
+	switch {
+	case key == "global":
+		return "global"
+	case key == "token":
+		if id := boot.rateLimitPrincipalId(c); id != "" {
+			return "user:" + id
+		}
+	case strings.HasPrefix(key, "header:"):
+		value := c.GetHeader(strings.TrimPrefix(key, "header:"))
+		if value != "" {
+			return "header:" + value
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// 获取请求令牌对应的用户ID，令牌无效时为空
+// 按照请求匹配的规则在认证之前检查，需要自行校验令牌
+func (boot *GobootApplication) rateLimitPrincipalId(c *gin.Context) string {
+	principal := GetPrincipal(c)
+	server := boot.Config.Goboot.Server
+	if principal == nil && boot.TokenStore != nil {
+		token := findAuthToken(c, authTokenName(server.Auth))
+		if token != "" && (boot.JwtManager == nil || !isJwtToken(token)) {
+			found, err := boot.TokenStore.Find(token)
+			if err != nil {
+				LogWarn("goboot rate limit find token failure: %v, request id: %v", err, GetRequestId(c))
+			}
+			principal = found
+		}
+	}
+	if principal == nil && boot.JwtManager != nil {
+		tokenName := server.Jwt.TokenName
+		if tokenName == "" {
+			tokenName = DefaultAuthTokenName
+		}
+		if token := findAuthToken(c, tokenName); token != "" {
+			claims, err := boot.JwtManager.Verify(token)
+			if err == nil && claims.Type != JwtTypeRefresh {
+				principal = claims.Principal()
+			}
+		}
+	}
+	if principal == nil {
+		return ""
+	}
+	if principal.Id != "" {
+		return principal.Id
+	}
+	return principal.Name
+}
+
+// 限流器，编译后的规则
+type rateLimiter struct {
+	// 按照请求匹配的规则，在中间件中检查
+	pathRules []int
+	// 按照映射函数匹配的规则，在映射函数调用前检查
+	methodRules []int
+	rules       []RateLimitRule
+}
+
+// 创建限流器
+func newRateLimiter(config RateLimit) *rateLimiter {
+	limiter := &rateLimiter{}
+	for idx, rule := range config.Rules {
+		if rule.Key == "" {
+			rule.Key = "ip"
+		}
+		if rule.Algorithm == "" {
+			rule.Algorithm = RateLimitTokenBucket
+		}
+		limiter.rules = append(limiter.rules, rule)
+		if len(rule.Methods) > 0 {
+			limiter.methodRules = append(limiter.methodRules, idx)
+		} else {
+			limiter.pathRules = append(limiter.pathRules, idx)
+		}
+	}
+	return limiter
+}
+
+// 根据配置创建限流存储
+// redis未开启时记录为启动错误，返回nil
+func (boot *GobootApplication) newRateLimitStore(config RateLimit) RateLimitStore {
+	if config.Store == "redis" {
+		if boot.Redis == nil {
+			boot.startupErrors = append(boot.startupErrors, "goboot.server.rateLimit.store: redis store require enable redis config [goboot.server.redis.enable]")
+			return nil
+		}
+		return NewRedisRateLimitStore(boot.Redis)
+	}
+	return NewMemoryRateLimitStore()
+}
+
+// 检查规则，设置响应头，被限流时响应429
+// 返回是否允许继续处理
+func (boot *GobootApplication) applyRateLimit(c *gin.Context, indexes []int, route *MappingRoute) bool {
+	limiter := boot.rateLimiter
+	// 按函数名匹配其他写法的映射请求，使用映射路由的路径
+	matched := route
+	if matched == nil {
+		matched = boot.mappingFallbackRoute(c)
+	}
+	var least *RateLimitResult
+	for _, idx := range indexes {
+		rule := limiter.rules[idx]
+		if !rule.matchRequest(c, matched) {
+			continue
+		}
+		if route != nil && !rule.matchRoute(route) {
+			continue
+		}
+		key := rule.name(idx) + ":" + boot.rateLimitIdentity(c, rule)
+		result, err := boot.RateLimitStore.Allow(key, rule)
+		if err != nil {
+			LogWarn("goboot rate limit %v failure: %v, request id: %v", rule.name(idx), err, GetRequestId(c))
+			continue
+		}
+		if least == nil || !result.Allowed || (least.Allowed && result.Remaining < least.Remaining) {
+			least = result
+		}
+		if !result.Allowed {
+			break
+		}
+	}
+	if least == nil {
+		return true
+	}
+	header := c.Writer.Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(least.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(least.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(least.Reset)))
+	if least.Allowed {
+		return true
+	}
+	header.Set("Retry-After", strconv.Itoa(ceilSeconds(least.RetryAfter)))
+	boot.HandleError(c, NewStatusError(429, "too many requests"))
+	c.Abort()
+	return false
+}
+
+// 向上取整的秒数，至少为1秒
+func ceilSeconds(duration time.Duration) int {
+	seconds := int(math.Ceil(duration.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// 限流中间件，检查按照请求匹配的规则
+func (boot *GobootApplication) rateLimitMiddleware(config RateLimit) gin.HandlerFunc {
+	if !config.Enable {
+		return NextHandler
+	}
+	LogInfo("goboot enable rate limit, %v rule(s)", len(config.Rules))
+	if len(boot.rateLimiter.pathRules) == 0 {
+		return NextHandler
+	}
+	return func(c *gin.Context) {
+		if !boot.applyRateLimit(c, boot.rateLimiter.pathRules, nil) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// 检查按照映射函数匹配的规则
+func (boot *GobootApplication) checkRouteRateLimit(c *gin.Context, route *MappingRoute) bool {
+	if boot.rateLimiter == nil || len(boot.rateLimiter.methodRules) == 0 {
+		return true
+	}
+	return boot.applyRateLimit(c, boot.rateLimiter.methodRules, route)
+}
+
+// 令牌桶的结果
+func tokenBucketResult(rule RateLimitRule, allowed bool, tokens float64) *RateLimitResult {
+	rate := float64(rule.Limit) / rule.window().Seconds()
+	result := &RateLimitResult{
+		Allowed:   allowed,
+		Limit:     rule.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(rule.Limit) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+// 滑动窗口的结果，elapsed 为当前窗口已经经过的时间
+func slidingWindowResult(rule RateLimitRule, allowed bool, prev int64, curr int64, elapsed time.Duration) *RateLimitResult {
+	window := rule.window()
+	weight := float64(window-elapsed) / float64(window)
+	count := float64(prev)*weight + float64(curr)
+	result := &RateLimitResult{
+		Allowed:   allowed,
+		Limit:     rule.Limit,
+		Remaining: int(math.Max(0, math.Floor(float64(rule.Limit)-count))),
+	}
+	// 当前窗口的计数影响到下一个窗口结束
+	if curr > 0 {
+		result.Reset = 2*window - elapsed
+	} else if prev > 0 {
+		result.Reset = window - elapsed
+	}
+	if !allowed {
+		// 前一个窗口的权重降低到可以通过的时间，当前窗口已满时等待到下一个窗口
+		result.RetryAfter = window - elapsed
+		if prev > 0 && curr < int64(rule.Limit) {
+			wait := time.Duration((1-float64(int64(rule.Limit)-curr)/float64(prev))*float64(window)) - elapsed
+			if wait > 0 && wait < result.RetryAfter {
+				result.RetryAfter = wait
+			}
+		}
+	}
+	return result
+}
+
+// /////////////////////////////////////////////////////////
+// 内存限流存储，只适用于单个节点
+// /////////////////////////////////////////////////////////
+
+type memoryRateLimitState struct {
+	// 令牌桶
+	tokens float64
+	last   time.Time
+	// 滑动窗口
+	index int64
+	prev  int64
+	curr  int64
+	// 最后使用时间之后多久可以清理
+	idle   time.Duration
+	access time.Time
+}
+
+type MemoryRateLimitStore struct {
+	// 最多保存的计数数量，达到上限并且清理后仍然没有空间时，新的计数维度共用一个溢出计数
+	MaxStates int
+	lock      sync.Mutex
+	states    map[string]*memoryRateLimitState
+	checks    int
+	swept     int
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		MaxStates: DefaultMemoryRateLimitMaxStates,
+		states:    map[string]*memoryRateLimitState{},
+	}
+}
+
+// 清理过期的计数
+func (store *MemoryRateLimitStore) sweep(now time.Time) {
+	store.swept = store.checks
+	for item, state := range store.states {
+		if now.Sub(state.access) > state.idle {
+			delete(store.states, item)
+		}
+	}
+}
+
+func (store *MemoryRateLimitStore) Allow(key string, rule RateLimitRule) (*RateLimitResult, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	now := time.Now()
+	store.checks++
+	if store.checks%memoryRateLimitSweepCount == 0 {
+		store.sweep(now)
+	}
+
+	key = rule.Algorithm + ":" + key
+	state, ok := store.states[key]
+	if !ok && store.MaxStates > 0 && len(store.states) >= store.MaxStates {
+		// 计数已满时清理一次，清理后仍然已满时使用规则的溢出计数，避免随机的计数维度占满内存
+		if store.checks-store.swept >= memoryRateLimitSweepCount {
+			store.sweep(now)
+		}
+		if len(store.states) >= store.MaxStates {
+			key = fmt.Sprintf("%v:overflow:%v:%v/%v", rule.Algorithm, rule.Name, rule.Limit, rule.Window)
+			state, ok = store.states[key]
+		}
+	}
+	if !ok {
+		state = &memoryRateLimitState{
+			tokens: float64(rule.Limit),
+			last:   now,
+			idle:   2 * rule.window(),
+		}
+		store.states[key] = state
+	}
+	state.access = now
+
+	if rule.Algorithm == RateLimitSlidingWindow {
+		window := rule.window()
+		index := now.UnixNano() / int64(window)
+		elapsed := time.Duration(now.UnixNano() - index*int64(window))
+		if index == state.index+1 {
+			state.prev, state.curr = state.curr, 0
+		} else if index != state.index {
+			state.prev, state.curr = 0, 0
+		}
+		state.index = index
+		weight := float64(window-elapsed) / float64(window)
+		allowed := float64(state.prev)*weight+float64(state.curr) < float64(rule.Limit)
+		if allowed {
+			state.curr++
+		}
+		return slidingWindowResult(rule, allowed, state.prev, state.curr, elapsed), nil
+	}
+
+	rate := float64(rule.Limit) / rule.window().Seconds()
+	state.tokens = math.Min(float64(rule.Limit), state.tokens+now.Sub(state.last).Seconds()*rate)
+	state.last = now
+	allowed := state.tokens >= 1
+	if allowed {
+		state.tokens--
+	}
+	return tokenBucketResult(rule, allowed, state.tokens), nil
+}
+
+// /////////////////////////////////////////////////////////
+// redis限流存储，使用Lua脚本保证原子性，时间使用redis服务器的时间
+// 每个计数只使用一个键，适用于redis集群
+// /////////////////////////////////////////////////////////
+
+// 令牌桶脚本，ARGV: 容量，每毫秒补充的令牌数，过期时间毫秒
+var rateLimitTokenBucketScript = goredis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+// 滑动窗口脚本，ARGV: 窗口内允许的请求数，窗口毫秒
+var rateLimitSlidingWindowScript = goredis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local index = math.floor(now / window)
+local elapsed = now - index * window
+local data = redis.call('HMGET', KEYS[1], 'index', 'prev', 'curr')
+local last = tonumber(data[1])
+local prev = tonumber(data[2]) or 0
+local curr = tonumber(data[3]) or 0
+if last == index - 1 then
+	prev = curr
+	curr = 0
+elseif last ~= index then
+	prev = 0
+	curr = 0
+end
+local allowed = 0
+if prev * (window - elapsed) / window + curr < limit then
+	curr = curr + 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'index', index, 'prev', prev, 'curr', curr)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {allowed, prev, curr, elapsed}
+`)
+
+type RedisRateLimitStore struct {
+	Redis *RedisCli
+}
+
+func NewRedisRateLimitStore(redis *RedisCli) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		Redis: redis,
+	}
+}
+
+func (store *RedisRateLimitStore) Allow(key string, rule RateLimitRule) (*RateLimitResult, error) {
+	window := rule.window()
+	redisKey := rateLimitRedisKeyPrefix + rule.Algorithm + ":" + key
+	if rule.Algorithm == RateLimitSlidingWindow {
+		ret, err := rateLimitSlidingWindowScript.Run(store.Redis.Context, store.Redis.Redis, []string{redisKey}, rule.Limit, window.Milliseconds()).Int64Slice()
+		if err != nil {
+			return nil, err
+		}
+		if len(ret) != 4 {
+			return nil, fmt.Errorf("unexpected script result %v", ret)
+		}
+		return slidingWindowResult(rule, ret[0] == 1, ret[1], ret[2], time.Duration(ret[3])*time.Millisecond), nil
+	}
+
+	rate := float64(rule.Limit) / float64(window.Milliseconds())
+	ret, err := rateLimitTokenBucketScript.Run(store.Redis.Context, store.Redis.Redis, []string{redisKey}, rule.Limit, rate, 2*window.Milliseconds()).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) != 2 {
+		return nil, fmt.Errorf("unexpected script result %v", ret)
+	}
+	allowed, _ := ret[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprint(ret[1]), 64)
+	if err != nil {
+		return nil, err
+	}
+	return tokenBucketResult(rule, allowed == 1, tokens), nil
+}
@@ -0,0 +1,213 @@
+package goboot
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitTokenBucket(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	rule := RateLimitRule{Algorithm: RateLimitTokenBucket, Limit: 2, Window: 60}
+	for i, want := range []bool{true, true, false} {
+		result, err := store.Allow("k", rule)
+		if err != nil || result.Allowed != want {
+			t.Fatalf("request %v allowed = %v, %v", i, result.Allowed, err)
+		}
+		if want && result.Remaining != 1-i {
+			t.Fatalf("request %v remaining = %v", i, result.Remaining)
+		}
+	}
+	result, _ := store.Allow("k", rule)
+	if result.RetryAfter <= 0 || result.RetryAfter > 30*time.Second {
+		t.Fatalf("retry after = %v", result.RetryAfter)
+	}
+	if result, _ := store.Allow("other", rule); !result.Allowed {
+		t.Fatalf("other key should be allowed")
+	}
+}
+
+func TestMemoryRateLimitSlidingWindow(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	rule := RateLimitRule{Algorithm: RateLimitSlidingWindow, Limit: 2, Window: 3600}
+	for i, want := range []bool{true, true, false} {
+		result, err := store.Allow("k", rule)
+		if err != nil || result.Allowed != want {
+			t.Fatalf("request %v allowed = %v, %v", i, result.Allowed, err)
+		}
+	}
+}
+
+func TestMemoryRateLimitMaxStates(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	store.MaxStates = 2
+	rule := RateLimitRule{Algorithm: RateLimitTokenBucket, Limit: 1, Window: 60}
+	// 计数已满后，新的计数维度共用溢出计数
+	for i, item := range []struct {
+		key  string
+		want bool
+	}{{"a", true}, {"b", true}, {"c", true}, {"d", false}, {"a", false}} {
+		result, err := store.Allow(item.key, rule)
+		if err != nil || result.Allowed != item.want {
+			t.Fatalf("request %v of %v allowed = %v, %v", i, item.key, result.Allowed, err)
+		}
+	}
+	if len(store.states) != 3 {
+		t.Fatalf("states = %v, want 2 and overflow", len(store.states))
+	}
+}
+
+func TestSlidingWindowResult(t *testing.T) {
+	rule := RateLimitRule{Limit: 10, Window: 60}
+	// 前一个窗口10个请求，当前窗口经过一半，加权后计数为 5 + 6
+	result := slidingWindowResult(rule, false, 10, 6, 30*time.Second)
+	if result.Remaining != 0 || result.Reset != 90*time.Second {
+		t.Fatalf("result = %+v", result)
+	}
+	// 前一个窗口的权重降低到 0.4 时可以通过，即经过 36 秒
+	if result.RetryAfter != 6*time.Second {
+		t.Fatalf("retry after = %v", result.RetryAfter)
+	}
+	result = slidingWindowResult(rule, true, 0, 3, 10*time.Second)
+	if result.Remaining != 7 || result.RetryAfter != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+type rateLimitTestApi struct{}
+
+func (api *rateLimitTestApi) XP_Login() string { return "ok" }
+
+func newRateLimitTestApp(t *testing.T, trustedProxies []string) *GobootApplication {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.TrustedProxies = trustedProxies
+	config.Goboot.Server.Mapping.Enable = true
+	config.Goboot.Server.Mapping.Items = []string{"/api/"}
+	config.Goboot.Server.RateLimit = RateLimit{
+		Enable: true,
+		Rules:  []RateLimitRule{{Paths: []string{"/api/login"}, Limit: 1, Window: 60}},
+	}
+	boot := GetConfigApplication(config, nil)
+	if len(boot.startupErrors) > 0 {
+		t.Fatalf("startup errors: %v", boot.startupErrors)
+	}
+	boot.AddHandlers(&rateLimitTestApi{})
+	boot.compileMappings()
+	return boot
+}
+
+func serveRateLimitTest(boot *GobootApplication, target string, remoteAddr string, forwardedFor string) int {
+	req := httptest.NewRequest("POST", target, nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	boot.App.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	boot := newRateLimitTestApp(t, nil)
+	if code := serveRateLimitTest(boot, "/api/login", "192.0.2.1:1000", "198.51.100.1"); code != 200 {
+		t.Fatalf("first status = %v", code)
+	}
+	// 不信任代理时，伪造的 X-Forwarded-For 不能绕过限流
+	if code := serveRateLimitTest(boot, "/api/login", "192.0.2.1:1001", "198.51.100.2"); code != 429 {
+		t.Fatalf("spoofed forwarded for status = %v, want 429", code)
+	}
+	// 其他写法的路径同样限流
+	if code := serveRateLimitTest(boot, "/api/Login", "192.0.2.1:1002", ""); code != 429 {
+		t.Fatalf("alternate path status = %v, want 429", code)
+	}
+	if code := serveRateLimitTest(boot, "/api/login", "192.0.2.2:1000", ""); code != 200 {
+		t.Fatalf("other client status = %v", code)
+	}
+
+	// 信任的代理转发的请求使用 X-Forwarded-For 中的客户端IP
+	boot = newRateLimitTestApp(t, []string{"192.0.2.0/24"})
+	for _, client := range []string{"198.51.100.1", "198.51.100.2"} {
+		if code := serveRateLimitTest(boot, "/api/login", "192.0.2.1:1000", client); code != 200 {
+			t.Fatalf("client %v via trusted proxy status = %v", client, code)
+		}
+	}
+	if code := serveRateLimitTest(boot, "/api/login", "192.0.2.1:1000", "198.51.100.1"); code != 429 {
+		t.Fatalf("repeated client via trusted proxy status = %v, want 429", code)
+	}
+}
+
+func TestRateLimitTokenKey(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.Mapping.Enable = true
+	config.Goboot.Server.Mapping.Items = []string{"/api/"}
+	config.Goboot.Server.Auth = Auth{Enable: true, Excludes: []string{"/api/login"}}
+	config.Goboot.Server.RateLimit = RateLimit{
+		Enable: true,
+		Rules:  []RateLimitRule{{Paths: []string{"/api/**"}, Key: "token", Limit: 1, Window: 60}},
+	}
+	boot := GetConfigApplication(config, nil)
+	if len(boot.startupErrors) > 0 {
+		t.Fatalf("startup errors: %v", boot.startupErrors)
+	}
+	boot.AddHandlers(&rateLimitTestApi{})
+	boot.compileMappings()
+	if err := boot.TokenStore.Save("valid-token", &Principal{Id: "7"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(remoteAddr string, token string) int {
+		req := httptest.NewRequest("POST", "/api/login", nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		boot.App.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	// 无效的令牌使用客户端IP，每次请求使用随机令牌不能绕过限流
+	if code := serve("192.0.2.1:1000", "random-1"); code != 200 {
+		t.Fatalf("first random token status = %v", code)
+	}
+	if code := serve("192.0.2.1:1000", "random-2"); code != 429 {
+		t.Fatalf("second random token status = %v, want 429", code)
+	}
+	// 有效的令牌按照用户计数，与IP无关
+	if code := serve("192.0.2.1:1000", "valid-token"); code != 200 {
+		t.Fatalf("valid token status = %v", code)
+	}
+	if code := serve("192.0.2.2:1000", "valid-token"); code != 429 {
+		t.Fatalf("valid token from other ip status = %v, want 429", code)
+	}
+	if code := serve("192.0.2.2:1000", ""); code != 200 {
+		t.Fatalf("other ip without token status = %v", code)
+	}
+}
+
+func TestValidateTrustedProxies(t *testing.T) {
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	config.Goboot.Server.TrustedProxies = []string{"10.0.0.1", "10.0.0.0/8", "::1", "proxy.local"}
+	errs := ValidateGobootConfig(config)
+	if len(errs) != 1 || !containsConfigError(errs, "goboot.server.trustedProxies.3") {
+		t.Fatalf("errors = %v", errs)
+	}
+}
+
+func TestRateLimitStoreWithoutRedis(t *testing.T) {
+	resetTestLogging(t)
+	config := &GobootConfig{}
+	config.Goboot.Server.Port = 8080
+	boot := GetConfigApplication(config, nil)
+	if got := boot.newRateLimitStore(RateLimit{Enable: true, Store: "redis"}); got != nil {
+		t.Fatalf("store = %v, want nil without redis", got)
+	}
+	want := "goboot.server.rateLimit.store: redis store require enable redis config [goboot.server.redis.enable]"
+	if len(boot.startupErrors) != 1 || boot.startupErrors[0] != want {
+		t.Fatalf("startup errors = %v", boot.startupErrors)
+	}
+}
@@ -0,0 +1,62 @@
+package goboot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShutdownInvokesListenersOnce(t *testing.T) {
+	before, after := 0, 0
+	boot := &GobootApplication{
+		Listeners: &GobootLifecycleListener{
+			OnBeforeShutdown: []GobootListener{func(boot *GobootApplication) { before++ }},
+			OnShutdown:       []GobootListener{func(boot *GobootApplication) { after++ }},
+		},
+	}
+	for i := 0; i < 3; i++ {
+		if err := boot.Shutdown(context.Background()); err != nil {
+			t.Fatalf("shutdown error: %v", err)
+		}
+	}
+	if before != 1 || after != 1 {
+		t.Fatalf("listeners invoked before=%v after=%v, want 1 and 1", before, after)
+	}
+}
+
+func TestShutdownWaitsForHttpServer(t *testing.T) {
+	done := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(204)
+	})
+	server := httptest.NewUnstartedServer(handler)
+	server.Start()
+	defer server.Close()
+
+	boot := &GobootApplication{
+		Listeners:  &GobootLifecycleListener{},
+		HttpServer: server.Config,
+	}
+	go func() {
+		resp, err := http.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := boot.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown error: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("request not finished after shutdown")
+	}
+}
@@ -0,0 +1,212 @@
+package goboot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 请求追踪区
+// 每个请求都会分配请求ID和W3C traceparent，保存在gin上下文中
+// 请求头中已有 X-Request-Id 或者合法的 traceparent 时沿用，traceparent 会生成新的 span id
+// 请求ID会写入响应头，并在代理时转发到上游
+// 开启访问日志后，每个请求输出一条访问日志，日志器名称为 access
+// /////////////////////////////////////////////////////////
+
+// 默认请求ID的请求头名称
+const DefaultRequestIdHeader string = "X-Request-Id"
+
+// W3C trace context 请求头名称
+const TraceParentHeader string = "traceparent"
+
+// 默认访问日志格式
+const DefaultAccessLogFormat string = `{clientIp} {user} "{method} {path}" {status} {bytes} {latency} {requestId}`
+
+// gin上下文中保存的键
+const (
+	ContextRequestIdKey   string = "goboot.requestId"
+	ContextTraceParentKey string = "goboot.traceparent"
+	ContextTraceIdKey     string = "goboot.traceId"
+	// 当前用户，设置后会输出到访问日志中
+	ContextUserKey string = "goboot.user"
+	// 已经输出访问日志，gin的请求日志会跳过
+	contextAccessLoggedKey string = "goboot.accessLogged"
+	// 请求ID的请求头名称
+	contextRequestIdHeaderKey string = "goboot.requestIdHeader"
+)
+
+// 请求追踪配置
+type Trace struct {
+	RequestIdHeader string    `yaml:"requestIdHeader"` // 请求ID的请求头名称，默认 X-Request-Id
+	AccessLog       AccessLog `yaml:"accessLog"`
+}
+
+// 访问日志配置
+type AccessLog struct {
+	Enable bool `yaml:"enable"`
+	// 日志格式，可用变量：
+	// {method} {path} {query} {status} {latency} {bytes} {clientIp} {user} {requestId} {traceId} {userAgent} {referer}
+	Format       string   `yaml:"format"`
+	ExcludePaths []string `yaml:"excludePaths"` // 不输出访问日志的路径前缀
+}
+
+// traceparent 格式：版本-trace id-parent id-标志
+var traceParentRegex = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// 访问日志格式中的变量
+var accessLogVarRegex = regexp.MustCompile(`\{(\w+)\}`)
+
+// 生成指定字节数的随机十六进制字符串
+func randomHex(size int) string {
+	bytes := make([]byte, size)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// 请求ID是否可以沿用，避免日志注入
+func isValidRequestId(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, ch := range id {
+		if ch <= ' ' || ch > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// 根据请求中的 traceparent 生成当前请求的 traceparent
+// 请求中没有或者不合法时，生成新的 trace id
+func NextTraceParent(traceParent string) (string, string) {
+	traceId := ""
+	flags := "01"
+	match := traceParentRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(traceParent)))
+	if match != nil && match[1] != "ff" && match[2] != strings.Repeat("0", 32) && match[3] != strings.Repeat("0", 16) {
+		traceId = match[2]
+		flags = match[4]
+	} else {
+		traceId = randomHex(16)
+	}
+	return fmt.Sprintf("00-%v-%v-%v", traceId, randomHex(8), flags), traceId
+}
+
+// 请求追踪中间件，分配请求ID和traceparent，并输出访问日志
+func TraceMiddleware(trace Trace) gin.HandlerFunc {
+	header := trace.RequestIdHeader
+	if header == "" {
+		header = DefaultRequestIdHeader
+	}
+	accessLog := trace.AccessLog
+	format := accessLog.Format
+	if format == "" {
+		format = DefaultAccessLogFormat
+	}
+	logger := GetLogger("access")
+	if accessLog.Enable {
+		LogInfo("goboot enable access log, format: %v", format)
+	}
+
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(header)
+		if !isValidRequestId(requestId) {
+			requestId = strings.ReplaceAll(Tokens{}.MakeUUID(), "-", "")
+		}
+		traceParent, traceId := NextTraceParent(c.GetHeader(TraceParentHeader))
+		c.Set(ContextRequestIdKey, requestId)
+		c.Set(ContextTraceParentKey, traceParent)
+		c.Set(ContextTraceIdKey, traceId)
+		c.Set(contextRequestIdHeaderKey, header)
+		c.Header(header, requestId)
+		// 写回请求头，代理到上游时随请求头转发
+		c.Request.Header.Set(header, requestId)
+		c.Request.Header.Set(TraceParentHeader, traceParent)
+
+		excluded := false
+		for _, item := range accessLog.ExcludePaths {
+			if strings.HasPrefix(c.Request.URL.Path, item) {
+				excluded = true
+				break
+			}
+		}
+		if !accessLog.Enable || excluded {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		reqPath := c.Request.URL.Path
+		query := c.Request.URL.RawQuery
+		c.Set(contextAccessLoggedKey, true)
+
+		c.Next()
+
+		latency := time.Since(start)
+		bytes := c.Writer.Size()
+		if bytes < 0 {
+			bytes = 0
+		}
+		user := c.GetString(ContextUserKey)
+		if user == "" {
+			user = "-"
+		}
+		values := map[string]string{
+			"method":    c.Request.Method,
+			"path":      reqPath,
+			"query":     query,
+			"status":    fmt.Sprint(c.Writer.Status()),
+			"latency":   latency.String(),
+			"bytes":     fmt.Sprint(bytes),
+			"clientIp":  c.ClientIP(),
+			"user":      user,
+			"requestId": requestId,
+			"traceId":   traceId,
+			"userAgent": c.Request.UserAgent(),
+			"referer":   c.Request.Referer(),
+		}
+		line := accessLogVarRegex.ReplaceAllStringFunc(format, func(str string) string {
+			val, ok := values[str[1:len(str)-1]]
+			if !ok {
+				return str
+			}
+			return val
+		})
+		logger.Info(line)
+	}
+}
+
+// 获取当前请求的请求ID
+func GetRequestId(c *gin.Context) string {
+	return c.GetString(ContextRequestIdKey)
+}
+
+// 获取当前请求的traceparent，代理到上游时使用
+func GetTraceParent(c *gin.Context) string {
+	return c.GetString(ContextTraceParentKey)
+}
+
+// 获取当前请求的trace id
+func GetTraceId(c *gin.Context) string {
+	return c.GetString(ContextTraceIdKey)
+}
+
+// 获取当前请求的请求ID
+func (api *CtxResp) RequestId() string {
+	return GetRequestId(api.Context)
+}
+
+// 获取当前请求的traceparent
+func (api *CtxResp) TraceParent() string {
+	return GetTraceParent(api.Context)
+}
+
+// 获取当前请求的trace id
+func (api *CtxResp) TraceId() string {
+	return GetTraceId(api.Context)
+}
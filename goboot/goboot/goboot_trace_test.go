@@ -0,0 +1,61 @@
+package goboot
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNextTraceParent(t *testing.T) {
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	next, traceId := NextTraceParent(parent)
+	if traceId != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace id = %v", traceId)
+	}
+	if !strings.HasPrefix(next, "00-"+traceId+"-") || strings.Contains(next, "00f067aa0ba902b7") {
+		t.Fatalf("next traceparent = %v, want new parent id", next)
+	}
+
+	for _, item := range []string{"", "bad", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+		next, traceId := NextTraceParent(item)
+		if len(traceId) != 32 || traceId == strings.Repeat("0", 32) || !traceParentRegex.MatchString(next) {
+			t.Fatalf("invalid traceparent %v generated %v, %v", item, next, traceId)
+		}
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	resetTestLogging(t)
+	buf := &bytes.Buffer{}
+	SetLogHandler(slog.NewTextHandler(buf, nil))
+
+	engine := gin.New()
+	engine.Use(TraceMiddleware(Trace{AccessLog: AccessLog{Enable: true, Format: "{method} {path} {status} {requestId}"}}))
+	engine.GET("/ping", func(c *gin.Context) {
+		c.String(200, GetRequestId(c))
+	})
+
+	req := httptest.NewRequest("GET", "/ping", nil)
+	req.Header.Set(DefaultRequestIdHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Body.String() != "abc-123" || rec.Header().Get(DefaultRequestIdHeader) != "abc-123" {
+		t.Fatalf("request id not kept: body %v, header %v", rec.Body.String(), rec.Header().Get(DefaultRequestIdHeader))
+	}
+	if !strings.Contains(buf.String(), "GET /ping 200 abc-123") {
+		t.Fatalf("access log = %v", buf.String())
+	}
+
+	// 不合法的请求ID重新生成，避免日志注入
+	req = httptest.NewRequest("GET", "/ping", nil)
+	req.Header.Set(DefaultRequestIdHeader, "bad\nid")
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if got := rec.Header().Get(DefaultRequestIdHeader); len(got) != 32 {
+		t.Fatalf("request id = %v, want generated", got)
+	}
+}
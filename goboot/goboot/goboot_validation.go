@@ -0,0 +1,302 @@
+package goboot
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// /////////////////////////////////////////////////////////
+// goboot 请求参数校验区
+// 自动映射函数中注入的结构体参数，使用 uri 标签绑定路径变量，header/cookie/query 标签绑定对应的来源
+// 使用 c.ShouldBind 绑定请求参数，并按照 binding 标签校验
+// 绑定或校验失败时不调用函数，响应400，data中为每个字段的错误
+// 绑定后，结构体实现了 Validate() error 时调用，用于跨字段校验
+//
+// 错误信息按照语言配置 goboot.server.validation.locale 输出，内置 en, zh
+// 开启 acceptLanguage 时，优先使用请求头 Accept-Language 中的语言
+// 使用 RegisterValidationMessages 添加或覆盖语言的提示信息
+// /////////////////////////////////////////////////////////
+
+// 默认的校验信息语言
+const DefaultValidationLocale string = "en"
+
+// 校验配置
+type Validation struct {
+	Locale         string `yaml:"locale"`         // 错误信息的语言，默认 en
+	AcceptLanguage bool   `yaml:"acceptLanguage"` // 是否按照请求头 Accept-Language 选择语言
+}
+
+// 请求参数字段的错误
+type FieldError struct {
+	Field   string `json:"field"`           // 字段名，使用 json/form/uri 等标签的名称
+	Tag     string `json:"tag"`             // 校验规则，例如 required，类型错误时为 type
+	Param   string `json:"param,omitempty"` // 校验规则的参数，例如 min=3 中的3
+	Message string `json:"message"`
+}
+
+// 请求参数绑定或校验失败的错误
+type BindError struct {
+	Msg    string
+	Fields []FieldError
+	Err    error
+}
+
+func (err *BindError) Error() string {
+	return err.Msg
+}
+
+func (err *BindError) Unwrap() error {
+	return err.Err
+}
+
+func (err *BindError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// 结构体参数的自定义校验
+type MappingValidator interface {
+	Validate() error
+}
+
+// 校验提示信息，{field} {param} 会被替换
+// 键为校验规则，_invalid 为总体提示，_default 为未定义规则的提示，_type 为类型错误的提示
+var validationMessages = map[string]map[string]string{
+	"en": {
+		"_invalid": "invalid request params",
+		"_default": "{field} is invalid",
+		"_type":    "{field} has invalid type",
+		"required": "{field} is required",
+		"min":      "{field} must be at least {param}",
+		"max":      "{field} must be at most {param}",
+		"len":      "{field} length must be {param}",
+		"gt":       "{field} must be greater than {param}",
+		"gte":      "{field} must be greater than or equal to {param}",
+		"lt":       "{field} must be less than {param}",
+		"lte":      "{field} must be less than or equal to {param}",
+		"eq":       "{field} must be equal to {param}",
+		"ne":       "{field} must not be equal to {param}",
+		"oneof":    "{field} must be one of [{param}]",
+		"email":    "{field} must be a valid email",
+		"url":      "{field} must be a valid url",
+		"numeric":  "{field} must be numeric",
+		"alphanum": "{field} must be alphanumeric",
+		"eqfield":  "{field} must be equal to {param}",
+	},
+	"zh": {
+		"_invalid": "请求参数错误",
+		"_default": "{field}格式不正确",
+		"_type":    "{field}类型不正确",
+		"required": "{field}不能为空",
+		"min":      "{field}最小为{param}",
+		"max":      "{field}最大为{param}",
+		"len":      "{field}长度必须为{param}",
+		"gt":       "{field}必须大于{param}",
+		"gte":      "{field}必须大于或等于{param}",
+		"lt":       "{field}必须小于{param}",
+		"lte":      "{field}必须小于或等于{param}",
+		"eq":       "{field}必须等于{param}",
+		"ne":       "{field}不能等于{param}",
+		"oneof":    "{field}必须是[{param}]中的一个",
+		"email":    "{field}必须是有效的邮箱",
+		"url":      "{field}必须是有效的URL",
+		"numeric":  "{field}必须是数字",
+		"alphanum": "{field}只能包含字母和数字",
+		"eqfield":  "{field}必须与{param}相同",
+	},
+}
+
+var (
+	validationMessagesLock sync.RWMutex
+	bindingValidatorOnce   sync.Once
+)
+
+// 添加或覆盖指定语言的校验提示信息
+func RegisterValidationMessages(locale string, messages map[string]string) {
+	validationMessagesLock.Lock()
+	defer validationMessagesLock.Unlock()
+	locale = strings.ToLower(locale)
+	if validationMessages[locale] == nil {
+		validationMessages[locale] = map[string]string{}
+	}
+	for key, val := range messages {
+		validationMessages[locale][key] = val
+	}
+}
+
+// 获取校验提示信息
+// 语言中没有时使用默认语言，规则没有定义时使用 _default
+func ValidationMessage(locale string, tag string, field string, param string) string {
+	validationMessagesLock.RLock()
+	defer validationMessagesLock.RUnlock()
+	text := ""
+	for _, item := range []string{strings.ToLower(locale), DefaultValidationLocale} {
+		messages := validationMessages[item]
+		if val, ok := messages[tag]; ok {
+			text = val
+			break
+		}
+		if val, ok := messages["_default"]; ok && !strings.HasPrefix(tag, "_") {
+			text = val
+			break
+		}
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(text)
+}
+
+// 获取当前请求使用的语言
+func (boot *GobootApplication) validationLocale(c *gin.Context) string {
+	config := boot.Config.Goboot.Server.Validation
+	locale := strings.ToLower(config.Locale)
+	if locale == "" {
+		locale = DefaultValidationLocale
+	}
+	if !config.AcceptLanguage {
+		return locale
+	}
+	validationMessagesLock.RLock()
+	defer validationMessagesLock.RUnlock()
+	// 例如 zh-CN,zh;q=0.9,en;q=0.8
+	for _, item := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		lang := strings.ToLower(strings.TrimSpace(strings.Split(item, ";")[0]))
+		if lang == "" {
+			continue
+		}
+		if _, ok := validationMessages[lang]; ok {
+			return lang
+		}
+		if idx := strings.Index(lang, "-"); idx > 0 {
+			if _, ok := validationMessages[lang[:idx]]; ok {
+				return lang[:idx]
+			}
+		}
+	}
+	return locale
+}
+
+// 设置gin校验器的字段名，使用 json/form/uri/header/cookie/query 标签的名称
+func initBindingValidator() {
+	bindingValidatorOnce.Do(func() {
+		validate, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri", "header", "cookie", "query"} {
+				name := strings.Split(field.Tag.Get(tag), ",")[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	})
+}
+
+// 绑定并校验请求参数，bindParam 为结构体指针
+// 失败时返回 *BindError
+func (boot *GobootApplication) BindMappingParam(c *gin.Context, bindParam interface{}) error {
+	initBindingValidator()
+	// 先绑定路径变量，之后与请求参数一起校验
+	if len(c.Params) > 0 {
+		params := map[string][]string{}
+		for _, item := range c.Params {
+			params[item.Key] = []string{item.Value}
+		}
+		err := binding.MapFormWithTag(bindParam, params, "uri")
+		if err != nil {
+			return boot.newBindError(c, err)
+		}
+	}
+	// 使用 header/cookie/query 标签的字段，从对应的来源绑定
+	for _, tag := range []string{"header", "cookie", "query"} {
+		if !structHasTag(reflect.TypeOf(bindParam), tag) {
+			continue
+		}
+		var values map[string][]string
+		switch tag {
+		case "header":
+			values = mappingHeaderValues(c)
+		case "cookie":
+			values = mappingCookieValues(c)
+		default:
+			values = c.Request.URL.Query()
+		}
+		err := binding.MapFormWithTag(bindParam, values, tag)
+		if err != nil {
+			return boot.newBindError(c, err)
+		}
+	}
+	err := c.ShouldBind(bindParam)
+	// 没有请求体时，不作为错误
+	if errors.Is(err, io.EOF) {
+		err = nil
+		if binding.Validator != nil {
+			err = binding.Validator.ValidateStruct(bindParam)
+		}
+	}
+	return boot.validateMappingParam(c, bindParam, err)
+}
+
+// 将绑定和校验的错误转换为 *BindError
+func (boot *GobootApplication) newBindError(c *gin.Context, err error) error {
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		return bindErr
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr
+	}
+	locale := boot.validationLocale(c)
+	ret := &BindError{
+		Fields: []FieldError{},
+		Err:    err,
+	}
+
+	var fieldErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &fieldErrs) {
+		for _, item := range fieldErrs {
+			// 去掉命名空间中的结构体名称
+			field := item.Namespace()
+			if idx := strings.Index(field, "."); idx >= 0 {
+				field = field[idx+1:]
+			}
+			ret.Fields = append(ret.Fields, FieldError{
+				Field:   field,
+				Tag:     item.Tag(),
+				Param:   item.Param(),
+				Message: ValidationMessage(locale, item.Tag(), field, item.Param()),
+			})
+		}
+	} else if errors.As(err, &typeErr) {
+		ret.Fields = append(ret.Fields, FieldError{
+			Field:   typeErr.Field,
+			Tag:     "type",
+			Param:   typeErr.Type.String(),
+			Message: ValidationMessage(locale, "_type", typeErr.Field, typeErr.Type.String()),
+		})
+	}
+
+	msgs := []string{}
+	for _, item := range ret.Fields {
+		msgs = append(msgs, item.Message)
+	}
+	if len(msgs) == 0 {
+		msgs = append(msgs, err.Error())
+	}
+	ret.Msg = ValidationMessage(locale, "_invalid", "", "") + ": " + strings.Join(msgs, "; ")
+	return ret
+}
@@ -0,0 +1,94 @@
+package goboot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type validationTestUser struct {
+	Name  string `json:"name" binding:"required"`
+	Age   int    `json:"age" binding:"min=18"`
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+func (user *validationTestUser) Validate() error {
+	if user.Name == "root" {
+		return errors.New("name is reserved")
+	}
+	return nil
+}
+
+type validationTestApi struct{}
+
+func (api *validationTestApi) XP_User(user *validationTestUser) string {
+	return "ok " + user.Name
+}
+
+func postValidationTest(boot *GobootApplication, body string, lang string) (int, ApiResp) {
+	req := httptest.NewRequest("POST", "/api/user", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+	rec := httptest.NewRecorder()
+	boot.App.ServeHTTP(rec, req)
+	resp := ApiResp{}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+func TestMappingParamValidation(t *testing.T) {
+	boot := newMappingTestApp(t, &validationTestApi{})
+	boot.Config.Goboot.Server.Validation.AcceptLanguage = true
+
+	code, resp := postValidationTest(boot, `{"age":10,"email":"bad"}`, "")
+	if code != 400 || !strings.HasPrefix(resp.Msg, "invalid request params") {
+		t.Fatalf("response = %v %+v", code, resp)
+	}
+	fields := resp.Data.([]interface{})
+	want := []string{"name is required", "age must be at least 18", "email must be a valid email"}
+	if len(fields) != len(want) {
+		t.Fatalf("fields = %v", fields)
+	}
+	for i, item := range fields {
+		if msg := item.(map[string]interface{})["message"]; msg != want[i] {
+			t.Errorf("field %v message = %v, want %v", i, msg, want[i])
+		}
+	}
+
+	code, resp = postValidationTest(boot, `{"age":10,"name":"tom"}`, "zh-CN,zh;q=0.9")
+	if code != 400 || !strings.Contains(resp.Msg, "age最小为18") {
+		t.Fatalf("zh response = %v %+v", code, resp)
+	}
+
+	code, resp = postValidationTest(boot, `{"age":20,"name":"root"}`, "")
+	if code != 400 || !strings.HasSuffix(resp.Msg, "name is reserved") {
+		t.Fatalf("custom validate response = %v %+v", code, resp)
+	}
+
+	req := httptest.NewRequest("POST", "/api/user", strings.NewReader(`{"age":20,"name":"tom"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	boot.App.ServeHTTP(rec, req)
+	if rec.Code != 200 || rec.Body.String() != "ok tom" {
+		t.Fatalf("valid response = %v %v", rec.Code, rec.Body.String())
+	}
+}
+
+func TestValidationMessage(t *testing.T) {
+	RegisterValidationMessages("fr", map[string]string{"required": "{field} est requis"})
+	cases := map[[2]string]string{
+		{"fr", "required"}: "name est requis",
+		{"fr", "min"}:      "name must be at least 3",
+		{"xx", "unknown"}:  "name is invalid",
+		{"zh", "min"}:      "name最小为3",
+	}
+	for key, want := range cases {
+		if got := ValidationMessage(key[0], key[1], "name", "3"); got != want {
+			t.Errorf("ValidationMessage(%v, %v) = %v, want %v", key[0], key[1], got, want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"goboot/goboot"
 	"io/fs"
 	"net/http"
+	"os"
 
 	// "time"
 
@@ -91,6 +92,8 @@ func (admin *AdminController) XP_Get(ctx *goboot.CtxResp) any {
 var staticFiles embed.FS
 
 func main() {
+	goboot.RunGobootCommand(os.Args[1:])
+
 	cfgFile := goboot.DefaultConfigFile
 	var listener *goboot.GobootLifecycleListener =nil
 	goboot.LogInfo("use config yaml %v initial application with listener %v", cfgFile, listener)
@@ -1,3 +1,12 @@
 PID=`cat goboot.pid`
+kill -15 $PID
+# 等待优雅停机完成，超时后强制结束
+for i in $(seq 1 60); do
+    if ! kill -0 $PID 2>/dev/null; then
+        echo stop ok pid=$PID
+        exit 0
+    fi
+    sleep 1
+done
 kill -9 $PID
 echo kill pid=$PID
@@ -1,21 +1,58 @@
 package gorpc
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/rpc"
 	"net/rpc/jsonrpc"
+	"os"
+	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 )
 
 // /////////////////////////////////////////////////////////
 // gorpc Log区
+// 基于 log/slog 的分级日志，Log* 系列函数是默认日志器的简单封装
+// 可以使用 SetLogLevel 设置日志级别，使用 SetLogger 替换日志器
 // /////////////////////////////////////////////////////////
-// 控制台日志输出
+
+// 日志级别，默认info
+var logLevel = new(slog.LevelVar)
+
+// 默认日志器
+var logger atomic.Pointer[slog.Logger]
+
+func init() {
+	SetLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
+}
+
+// 替换日志器
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// 设置默认日志器的日志级别
+func SetLogLevel(level slog.Level) {
+	logLevel.Set(level)
+}
+
+// 日志输出，level 为 DEBUG/INFO/WARN/ERROR，不区分大小写
 func Log(level string, format string, args ...interface{}) {
-	time := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Println(fmt.Sprintf("[%v] [%v]", time, level), fmt.Sprintf(format, args...))
+	var msgLevel slog.Level
+	if err := msgLevel.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		msgLevel = slog.LevelInfo
+	}
+	l := logger.Load()
+	if !l.Enabled(context.Background(), msgLevel) {
+		return
+	}
+	l.Log(context.Background(), msgLevel, fmt.Sprintf(format, args...))
+}
+func LogDebug(format string, args ...interface{}) {
+	Log("DEBUG", format, args...)
 }
 func LogInfo(format string, args ...interface{}) {
 	Log("INFO ", format, args...)